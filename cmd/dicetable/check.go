@@ -0,0 +1,122 @@
+package main
+
+import (
+	"dicetable/pkg/dice"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A pool with modifiers after it, like 1d20+3 or 2d6-1+2
+var check_term = regexp.MustCompile(`^(\d*d\d+)((?:[+-]\d+)*)$`)
+var check_modifier = regexp.MustCompile(`[+-]\d+`)
+
+// The values of the flags of check
+type checkValues struct {
+	dc     int
+	crit   int
+	fumble int
+	nocrit bool
+	quiet  bool
+	seed   int64
+}
+
+func (values *checkValues) declare(opts *options) *flag.FlagSet {
+	flags := newFlags("check")
+	flags.IntVar(&values.dc, "dc", 0, "The total to reach for a success")
+	flags.IntVar(&values.crit, "crit", 0, "A critical success when the dice show this many or more before modifiers. 0 is the highest they can roll")
+	flags.IntVar(&values.fumble, "fumble", 0, "A critical failure when the dice show this many or fewer before modifiers. 0 is the lowest they can roll")
+	flags.BoolVar(&values.nocrit, "nocrit", false, "Only ever exit with success or failure")
+	flags.BoolVar(&values.quiet, "q", false, "Don't print anything, only exit with the result")
+	flags.Int64Var(&values.seed, "seed", 0, "Seed the dice so the same rolls can be made again. 0 picks a new seed")
+	return flags
+}
+
+func checkFlags(opts *options) *flag.FlagSet {
+	return new(checkValues).declare(opts)
+}
+
+func checkMain(opts *options, args []string) int {
+	// Roll against a difficulty and exit with the result so shell scripts can branch on it.
+	// Exits 0 on a success, 1 on a failure, 3 on a critical success and 4 on a critical failure.
+	// Wrong flags or dice exit 2 and an error that stops it rolling at all exits 5, so neither is taken for a failure.
+	// A critical is decided by the dice alone: by default every die on its highest face or every die on 1
+	var values checkValues
+	flags := values.declare(opts)
+	dice_args, code, ok := parseInterspersed(flags, args)
+	if !ok {
+		return code
+	}
+	dc_set := false
+	flags.Visit(func(f *flag.Flag) { dc_set = dc_set || f.Name == "dc" })
+	if !dc_set || len(dice_args) == 0 {
+		fmt.Fprintln(os.Stderr, "check needs dice and a difficulty, like dicetable check 1d20+3 --dc 15.")
+		return exitUsage
+	}
+
+	// Take the modifiers off each argument and build the table from the pools that are left
+	pool_strings := make([]string, len(dice_args))
+	names := make([]string, len(dice_args))
+	modifier := 0
+	for n, arg := range dice_args {
+		match := check_term.FindStringSubmatch(strings.ToLower(arg))
+		if match == nil {
+			fmt.Fprintf(os.Stderr, "%s is not a pool like 1d20 or 1d20+3.\n", arg)
+			return exitUsage
+		}
+		pool_strings[n] = match[1]
+		if strings.HasPrefix(match[1], "d") {
+			pool_strings[n] = "1" + match[1]
+		}
+		names[n] = strconv.Itoa(n)
+		for _, m := range check_modifier.FindAllString(match[2], -1) {
+			value, _ := strconv.Atoi(m)
+			modifier += value
+		}
+	}
+	table, err := dice.ParseTableString(pool_strings, names)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	if values.seed != 0 {
+		dice.Seed(values.seed)
+	}
+	natural, lowest, highest := 0, 0, 0
+	var faces []string
+	for _, name := range names {
+		pool := table.Pools[name]
+		pool.Roll()
+		natural += pool.Total()
+		lowest += len(pool.Dice)
+		highest += len(pool.Dice) * pool.Sides
+		for _, face := range pool.List() {
+			faces = append(faces, strconv.Itoa(face))
+		}
+	}
+	if values.crit == 0 {
+		values.crit = highest
+	}
+	if values.fumble == 0 {
+		values.fumble = lowest
+	}
+	total := natural + modifier
+
+	result, code := "failure", exitFailure
+	switch {
+	case !values.nocrit && natural >= values.crit:
+		result, code = "critical success", exitCritical
+	case !values.nocrit && natural <= values.fumble:
+		result, code = "critical failure", exitFumble
+	case total >= values.dc:
+		result, code = "success", exitOK
+	}
+	if !values.quiet {
+		fmt.Printf("%s: [%s] %+d = %d vs DC %d, %s\n", strings.Join(dice_args, " "), strings.Join(faces, " "), modifier, total, values.dc, result)
+	}
+	return code
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"dicetable/internal/report"
+	"dicetable/pkg/dice"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func naturalRoll(seed int64) int {
+	// What a d20 shows when check rolls it with the seed
+	table, _ := dice.ParseTableString([]string{"1d20"}, []string{"0"})
+	dice.Seed(seed)
+	pool := table.Pools["0"]
+	pool.Roll()
+	return pool.Total()
+}
+
+func seedFor(t *testing.T, natural func(int) bool) string {
+	// The first seed that rolls a d20 the test needs
+	for seed := int64(1); seed < 10000; seed++ {
+		if natural(naturalRoll(seed)) {
+			return strconv.FormatInt(seed, 10)
+		}
+	}
+	t.Fatalf("No seed rolls the d20 needed")
+	return ""
+}
+
+func TestCheckExitCodes(t *testing.T) {
+	opts := &options{format: report.Text}
+	twenty := seedFor(t, func(n int) bool { return n == 20 })
+	one := seedFor(t, func(n int) bool { return n == 1 })
+	nineteen := seedFor(t, func(n int) bool { return n == 19 })
+	ten := seedFor(t, func(n int) bool { return n == 10 })
+
+	cases := []struct {
+		args []string
+		want int
+	}{
+		// The dice alone decide a critical, whatever the difficulty
+		{[]string{"1d20", "-dc", "30", "-q", "-seed", twenty}, exitCritical},
+		{[]string{"1d20+20", "-dc", "5", "-q", "-seed", one}, exitFumble},
+		{[]string{"1d20", "-dc", "30", "-nocrit", "-q", "-seed", twenty}, exitFailure},
+		{[]string{"1d20+20", "-dc", "5", "-nocrit", "-q", "-seed", one}, exitOK},
+		{[]string{"1d20", "-dc", "30", "-crit", "19", "-q", "-seed", nineteen}, exitCritical},
+		{[]string{"1d20", "-dc", "1", "-fumble", "10", "-q", "-seed", ten}, exitFumble},
+
+		// Every modifier is added to the natural roll: 10 + 3 - 1 = 12
+		{[]string{"1d20+3-1", "-dc", "12", "-q", "-seed", ten}, exitOK},
+		{[]string{"1d20+3-1", "-dc", "13", "-q", "-seed", ten}, exitFailure},
+		// The flags can come before, between or after the dice, and a pool without a count is one die
+		{[]string{"-q", "d20+2", "--dc", "12", "-seed", ten}, exitOK},
+		{[]string{"-seed", ten, "-q", "D20+1", "-dc=12"}, exitFailure},
+
+		// Without a difficulty or dice, or with something that isn't a pool, check doesn't roll
+		{[]string{"1d20", "-q"}, exitUsage},
+		{[]string{"-dc", "12", "-q"}, exitUsage},
+		{[]string{"1d20*2", "-dc", "12", "-q"}, exitUsage},
+		{[]string{"1d20", "-dc", "twelve", "-q"}, exitUsage},
+	}
+	for _, c := range cases {
+		if got := checkMain(opts, c.args); got != c.want {
+			t.Errorf("check %q should exit with %d, got %d", c.args, c.want, got)
+		}
+	}
+}
+
+func TestCheckErrorExitCode(t *testing.T) {
+	// A config that can't be loaded stops check rolling, which has to exit differently from a failed check
+	config_path := filepath.Join(t.TempDir(), "config")
+	os.WriteFile(config_path, []byte("rng = sometimes\n"), 0600)
+	previous, was_set := os.LookupEnv("DICETABLE_CONFIG")
+	os.Setenv("DICETABLE_CONFIG", config_path)
+	defer func() {
+		if was_set {
+			os.Setenv("DICETABLE_CONFIG", previous)
+		} else {
+			os.Unsetenv("DICETABLE_CONFIG")
+		}
+	}()
+
+	if got := run([]string{"-nolog", "check", "1d20", "-dc", "10", "-q"}); got != exitError {
+		t.Errorf("check should exit with %d when the config can't be loaded, got %d", exitError, got)
+	}
+	if got := run([]string{"roll", "1d6"}); got != exitFailure {
+		t.Errorf("Other subcommands should exit with %d when the config can't be loaded, got %d", exitFailure, got)
+	}
+}
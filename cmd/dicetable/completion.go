@@ -0,0 +1,205 @@
+package main
+
+import (
+	"dicetable/internal/report"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// The completion scripts only pass the words on the line to dicetable __complete and show what it prints,
+// so subcommands, flags and saved tables are always up to date. When nothing is printed the shell completes file names
+var completionScripts = map[string]string{
+	"bash": `# bash completion for dicetable. Load it with: source <(dicetable completion bash)
+_dicetable() {
+    local IFS=$'\n'
+    COMPREPLY=($(dicetable __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _dicetable dicetable
+`,
+	"zsh": `#compdef dicetable
+# zsh completion for dicetable. Load it with: source <(dicetable completion zsh)
+_dicetable() {
+    local -a candidates
+    candidates=("${(@f)$(dicetable __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if [[ -z "${candidates[*]}" ]]; then
+        _files
+    else
+        compadd -- "${candidates[@]}"
+    fi
+}
+compdef _dicetable dicetable
+`,
+	"fish": `# fish completion for dicetable. Load it with: dicetable completion fish | source
+function __dicetable_complete
+    set -l previous (commandline -opc)
+    set -l current (commandline -ct)
+    set -l candidates (dicetable __complete $previous[2..-1] "$current" 2>/dev/null)
+    if test (count $candidates) -eq 0
+        __fish_complete_path (commandline -ct)
+    else
+        printf '%s\n' $candidates
+    end
+end
+complete -c dicetable -f -a '(__dicetable_complete)'
+`,
+}
+
+func completionFlags(opts *options) *flag.FlagSet {
+	return newFlags("completion")
+}
+
+func completionMain(opts *options, args []string) int {
+	// Print the completion script for a shell. dicetable completion [bash/zsh/fish]
+	flags := completionFlags(opts)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	script, ok := completionScripts[flags.Arg(0)]
+	if flags.NArg() != 1 || !ok {
+		fmt.Fprintln(os.Stderr, "Give the shell to complete for: dicetable completion bash, zsh or fish.")
+		return exitUsage
+	}
+	fmt.Print(script)
+	return exitOK
+}
+
+// The flags a word can be completed with. A flag takes a value unless it is a bool
+type flagSet struct {
+	names  []string
+	values map[string]bool
+}
+
+func flagsOf(flags *flag.FlagSet) *flagSet {
+	// Collect the flags declared on a flag set. Nothing is parsed, so no subcommand runs
+	set := &flagSet{values: make(map[string]bool)}
+	flags.VisitAll(func(f *flag.Flag) {
+		set.names = append(set.names, "-"+f.Name)
+		bool_flag, ok := f.Value.(interface{ IsBoolFlag() bool })
+		set.values[f.Name] = !ok || !bool_flag.IsBoolFlag()
+	})
+	return set
+}
+
+func completeMain(opts *options, args []string) int {
+	// Print the words that could complete the last argument, one to a line. The arguments are the words
+	// after dicetable on the line being completed, the last of them is the one being typed
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	// bash splits -flag=value into three words
+	var words []string
+	for n := 0; n < len(args); n++ {
+		if args[n] == "=" && len(words) > 0 && strings.HasPrefix(words[len(words)-1], "-") {
+			if n == len(args)-1 {
+				words = append(words, "")
+			}
+			continue
+		}
+		words = append(words, args[n])
+	}
+	current := words[len(words)-1]
+	previous := words[:len(words)-1]
+
+	for _, candidate := range candidates(opts, previous, current) {
+		if strings.HasPrefix(candidate, current) {
+			fmt.Println(candidate)
+		}
+	}
+	return exitOK
+}
+
+func candidates(opts *options, previous []string, current string) []string {
+	// Find the subcommand and the flags before the word being completed
+	name := ""
+	var positional []string
+	set := flagsOf(mainFlags(opts))
+	for n := 0; n < len(previous); n++ {
+		word := previous[n]
+		if strings.HasPrefix(word, "-") {
+			flag_name := strings.TrimLeft(word, "-")
+			if i := strings.Index(flag_name, "="); i >= 0 {
+				continue
+			}
+			// The flag's value is the word being completed
+			if set.values[flag_name] && n == len(previous)-1 {
+				return flagValues(opts, flag_name)
+			}
+			if set.values[flag_name] {
+				n++
+			}
+			continue
+		}
+		if name == "" && len(positional) == 0 {
+			if _, ok := subcommands[word]; ok {
+				name = word
+				set = flagsOf(subcommands[name].flags(opts))
+				continue
+			}
+		}
+		positional = append(positional, word)
+		// table list, load and save each have their own flags
+		if name == "table" && len(positional) == 1 {
+			set = flagsOf(tableActionFlags(opts, word))
+		}
+	}
+
+	if i := strings.Index(current, "="); strings.HasPrefix(current, "-") && i >= 0 {
+		// -flag=value completes the value and keeps the flag in front of it
+		flag_name := strings.TrimLeft(current[:i], "-")
+		var values []string
+		for _, value := range flagValues(opts, flag_name) {
+			values = append(values, current[:i+1]+value)
+		}
+		return values
+	}
+	if strings.HasPrefix(current, "-") {
+		return set.names
+	}
+
+	switch {
+	case name == "" && len(positional) == 0:
+		var names []string
+		for command := range subcommands {
+			names = append(names, command)
+		}
+		sort.Strings(names)
+		return names
+	case name == "help" && len(positional) == 0:
+		return candidates(opts, nil, "")
+	case name == "completion" && len(positional) == 0:
+		return []string{"bash", "fish", "zsh"}
+	case name == "migrate" && len(positional) == 0:
+		return []string{"down", "status", "up"}
+	case name == "table" && len(positional) == 0:
+		return []string{"list", "load", "save"}
+	case name == "table" && len(positional) == 1 && (positional[0] == "load" || positional[0] == "save"):
+		return savedTables(opts)
+	}
+	return nil
+}
+
+func flagValues(opts *options, flag_name string) []string {
+	// The values a flag can take, or nothing to let the shell complete a file name
+	switch flag_name {
+	case "tablename":
+		return savedTables(opts)
+	case "format":
+		return report.Formats
+	}
+	return nil
+}
+
+func savedTables(opts *options) []string {
+	files, err := openStore(opts.config).List()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, len(files))
+	for n, file := range files {
+		names[n] = file.Name
+	}
+	return names
+}
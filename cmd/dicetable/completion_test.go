@@ -0,0 +1,88 @@
+package main
+
+import (
+	"dicetable/internal/config"
+	"dicetable/internal/report"
+	"dicetable/pkg/dice"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func completionOptions(t *testing.T) (*options, string) {
+	// Options whose config file keeps the saved tables in a temporary directory with one table in it
+	dir := t.TempDir()
+	tables := filepath.Join(dir, "tables")
+	config_path := filepath.Join(dir, "config")
+	os.WriteFile(config_path, []byte("tables.dir = "+tables+"\n"), 0600)
+	file, err := config.Load(config_path)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	os.Unsetenv("DICETABLE_TABLES_DIR")
+	table, _ := dice.ParseTableString([]string{"4d6"}, []string{"strength"})
+	table.Name = "camp"
+	if err := openStore(file).Save(&table); err != nil {
+		t.Fatalf("%v", err)
+	}
+	return &options{format: report.Text, config: file}, tables
+}
+
+func TestCandidates(t *testing.T) {
+	opts, tables := completionOptions(t)
+	before := *opts
+	cases := []struct {
+		previous []string
+		current  string
+		want     []string
+	}{
+		{nil, "", []string{"check", "completion", "exec", "help", "migrate", "repl", "roll", "serve", "simulate", "stats", "table"}},
+		{[]string{"help"}, "", []string{"check", "completion", "exec", "help", "migrate", "repl", "roll", "serve", "simulate", "stats", "table"}},
+		{[]string{"completion"}, "", []string{"bash", "fish", "zsh"}},
+		{[]string{"migrate"}, "", []string{"down", "status", "up"}},
+		{[]string{"migrate"}, "-", []string{"-db", "-n"}},
+		{[]string{"table"}, "", []string{"list", "load", "save"}},
+		{[]string{"table"}, "-", nil},
+		{[]string{"table", "list"}, "-", nil},
+		{[]string{"table", "load"}, "", []string{"camp"}},
+		{[]string{"table", "save"}, "-", []string{"-names", "-replay"}},
+		{[]string{"check"}, "-", []string{"-crit", "-dc", "-fumble", "-nocrit", "-q", "-seed"}},
+		{[]string{"check", "-dc"}, "", nil},
+		{[]string{"serve"}, "-", []string{"-addr"}},
+		{[]string{"roll", "-format"}, "", report.Formats},
+		{[]string{"roll"}, "-format=", []string{"-format=text", "-format=json", "-format=csv", "-format=markdown", "-format=tsv"}},
+		{[]string{"-tablename"}, "", []string{"camp"}},
+		{[]string{"-nolog", "exec"}, "-", []string{"-format", "-logdir", "-nolog", "-tablename"}},
+	}
+	for _, c := range cases {
+		got := candidates(opts, c.previous, c.current)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Completing %q after %q should give %q, got %q", c.current, strings.Join(c.previous, " "), c.want, got)
+		}
+	}
+
+	// Completing only declares flags, so nothing runs: the options and the saved tables are left as they were
+	if !reflect.DeepEqual(*opts, before) {
+		t.Errorf("Completing shouldn't change the options, got %+v", *opts)
+	}
+	files, _ := os.ReadDir(tables)
+	if len(files) != 1 {
+		t.Errorf("Completing shouldn't write to the table store, got %d files", len(files))
+	}
+}
+
+func TestSubcommandFlags(t *testing.T) {
+	// help and completion show the flags each subcommand parses, so every subcommand has to declare them
+	opts, _ := completionOptions(t)
+	for name, command := range subcommands {
+		if command.flags == nil {
+			t.Errorf("%s doesn't declare its flags", name)
+			continue
+		}
+		if flags := command.flags(opts); flags.Name() != "dicetable "+name {
+			t.Errorf("%s declares the flags of %s", name, flags.Name())
+		}
+	}
+}
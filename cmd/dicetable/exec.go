@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"dicetable/internal/tablecommands"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+func execFlags(opts *options) *flag.FlagSet {
+	flags := newFlags("exec")
+	flags.StringVar(&opts.tablename, "tablename", opts.tablename, "The saved table to run the commands against. It is made if it hasn't been saved")
+	opts.bindFormat(flags)
+	opts.bindLog(flags)
+	return flags
+}
+
+func execMain(opts *options, args []string) int {
+	// Load a saved table, run commands against it, save it and print what the commands did.
+	// Each argument is a command. Without any the commands are read from stdin, one to a line
+	flags := execFlags(opts)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	commands := flags.Args()
+	if len(commands) == 0 {
+		if term.IsTerminal(int(os.Stdin.Fd())) {
+			fmt.Fprintln(os.Stderr, "Give the commands to run, like dicetable -tablename=X exec \"roll pool strength\", or pipe them in.")
+			return exitUsage
+		}
+		var err error
+		commands, err = readCommands()
+		if err != nil {
+			return fail(err)
+		}
+	}
+	return execCommands(opts, commands)
+}
+
+func readCommands() ([]string, error) {
+	var commands []string
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		commands = append(commands, scanner.Text())
+	}
+	return commands, scanner.Err()
+}
+
+func execCommands(opts *options, commands []string) int {
+	if opts.tablename == "" {
+		fmt.Fprintln(os.Stderr, "Give the table to run the commands against with -tablename, or set table in the config file.")
+		return exitUsage
+	}
+	if err := tablecommands.SetFormat(opts.format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	store, err := setup(opts)
+	if err != nil {
+		return fail(err)
+	}
+	table, err := store.Load(opts.tablename)
+	if err != nil && !errors.Is(err, tablecommands.ErrNoTable) {
+		return fail(err)
+	}
+	if _, err := tablecommands.StartLog(table.Name); err != nil {
+		return fail(err)
+	}
+
+	// Every table the commands left open is saved, even if a command failed, the ones before it may have changed them.
+	// That includes tables switched to with table new, table use or table load
+	tables, run_err := tablecommands.Exec(commands, &table, os.Stdout, os.Stderr)
+	for _, open_table := range tables {
+		if err := store.Save(open_table); err != nil {
+			return fail(err)
+		}
+	}
+	if run_err != nil {
+		return exitFailure
+	}
+	return exitOK
+}
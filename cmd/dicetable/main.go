@@ -1,38 +1,408 @@
 package main
 
 import (
+	"dicetable/internal/config"
+	"dicetable/internal/report"
 	"dicetable/internal/tablecommands"
 	"dicetable/pkg/dice"
 	"flag"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
+// Exit codes shared by every subcommand
+const (
+	exitOK      = 0
+	exitFailure = 1 // a command or a script failed, or a file couldn't be read
+	exitUsage   = 2 // the flags or arguments were wrong
+	// check exits with these when the dice alone decide the result
+	exitCritical = 3
+	exitFumble   = 4
+	// check exits with this when it couldn't roll, so an error isn't taken for a failed check
+	exitError = 5
+)
+
+// The flags every subcommand shares. They can be given before the subcommand or after it
+type options struct {
+	interactive bool
+	names       string
+	tablename   string
+	script      string
+	keep_going  bool
+	replay      string
+	logdir      string
+	nolog       bool
+	format      string
+	// the config file the defaults of the flags came from
+	config *config.File
+}
+
+type subcommand struct {
+	usage   string
+	summary string
+	run     func(opts *options, args []string) int
+	// flags declares the flags run parses without running anything, for help and completion
+	flags func(opts *options) *flag.FlagSet
+}
+
+// The subcommands by name. Filled in by init because help lists them
+var subcommands map[string]subcommand
+
+func init() {
+	subcommands = map[string]subcommand{
+		"roll":       {"roll [flags] [XdY...]", "Roll pools of dice once and print them.", rollMain, rollFlags},
+		"repl":       {"repl [flags] [XdY...]", "Open the interactive prompt. A table saved with -tablename is loaded first.", replMain, replFlags},
+		"stats":      {"stats [flags] [pool names...]", "Compare the rolls of a saved or replayed table to what fair dice would roll.", statsMain, statsFlags},
+		"simulate":   {"simulate [flags] [dice expression]", "Roll a dice expression many times and show how the totals fall.", simulateMain, simulateFlags},
+		"table":      {"table [list/load/save] [flags] [name] [XdY...]", "List, print and save the tables kept between sessions.", tableMain, tableFlags},
+		"serve":      {"serve [flags]", "Serve the saved tables over HTTP.", serveMain, serveFlags},
+		"check":      {"check [flags] [XdY+N...] -dc [difficulty]", "Roll against a difficulty and exit with 0 on a success, 1 on a failure, 3 on a critical success, 4 on a critical failure and 5 if it couldn't roll.", checkMain, checkFlags},
+		"exec":       {"exec [flags] [commands...]", "Run table commands against a saved table and save it. Without commands they are read from stdin.", execMain, execFlags},
+		"migrate":    {"migrate [flags] [up/down/status]", "Apply, undo or list the migrations of the diceapi database.", migrateMain, migrateFlags},
+		"completion": {"completion [bash/zsh/fish]", "Print a script that completes dicetable in a shell.", completionMain, completionFlags},
+		"help":       {"help [subcommand]", "Show help for dicetable or one of its subcommands.", helpMain, helpFlags},
+	}
+}
+
 func main() {
-	interactivePtr := flag.Bool("i", false, "Start interactive table prompt")
-	namesPtr := flag.String("names", "", "Names for the dice pools entered. Seperate each by a coma with no space")
-	tablenamePtr := flag.String("tablename", "", "Names the table. Changes the prompt.")
-	flag.Parse()
-	dice_args := flag.Args()
-	var names []string
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	// The flags are parsed even when the config can't be loaded so the error can be reported the way the subcommand needs
+	opts, load_err := loadOptions()
+	flags := mainFlags(opts)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	rest := flags.Args()
+	if load_err != nil {
+		code := fail(load_err)
+		if len(rest) > 0 && rest[0] == "check" {
+			code = exitError
+		}
+		return code
+	}
+
+	if len(rest) > 0 {
+		// Called by the completion scripts, so it isn't listed with the other subcommands
+		if rest[0] == "__complete" {
+			return completeMain(opts, rest[1:])
+		}
+		if command, ok := subcommands[rest[0]]; ok {
+			return command.run(opts, rest[1:])
+		}
+	}
+
+	// Without a subcommand the old flags still work. -i, -script and -replay work on a table like repl does
+	// and anything else is rolled
+	if opts.interactive || opts.script != "" || opts.replay != "" {
+		return openTable(opts, rest, opts.interactive)
+	}
+	if len(rest) == 0 {
+		usage(flags)
+		return exitUsage
+	}
+	return rollMain(opts, rest)
+}
+
+func loadOptions() (*options, error) {
+	// Start the flags from the config file so they only need to be given to change it.
+	// table is the default -tablename and format the default -format
+	opts := &options{format: report.Text}
+	path, err := config.Path()
+	if err != nil {
+		return opts, err
+	}
+	opts.config, err = config.Load(path)
+	if err != nil {
+		return opts, err
+	}
+	if table, ok := opts.config.Get("table"); ok {
+		opts.tablename = table
+	}
+	if format, ok := opts.config.Get("format"); ok {
+		opts.format = format
+	}
+	return opts, applyRNG(opts.config)
+}
+
+func applyRNG(file *config.File) error {
+	// rng = time seeds the dice from the clock, crypto takes every new seed from the operating system,
+	// and a number seeds the dice with it so every run rolls the same
+	value, ok := file.Get("rng")
+	switch {
+	case !ok || value == "time":
+		return nil
+	case value == "crypto":
+		dice.UseCrypto(true)
+		return nil
+	}
+	seed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s: rng: %s is not time, crypto or a seed", file.Path, value)
+	}
+	dice.Seed(seed)
+	return nil
+}
+
+func mainFlags(opts *options) *flag.FlagSet {
+	// The flags given before a subcommand, or without one
+	flags := flag.NewFlagSet("dicetable", flag.ContinueOnError)
+	flags.Usage = func() { usage(flags) }
+	opts.bind(flags)
+	return flags
+}
+
+func (opts *options) bind(flags *flag.FlagSet) {
+	// Add every shared flag to a flag set. The value already set is the default so flags given before
+	// the subcommand carry over to it
+	flags.BoolVar(&opts.interactive, "i", opts.interactive, "Start interactive table prompt")
+	opts.bindTable(flags)
+	flags.StringVar(&opts.script, "script", opts.script, "Run the table commands in a file, or - for stdin, before anything else")
+	flags.BoolVar(&opts.keep_going, "continue", opts.keep_going, "Keep running a script after a command fails")
+	flags.StringVar(&opts.replay, "replay", opts.replay, "Rebuild the table from a log file before anything else")
+	opts.bindLog(flags)
+	opts.bindFormat(flags)
+}
+
+func (opts *options) bindTable(flags *flag.FlagSet) {
+	flags.StringVar(&opts.names, "names", opts.names, "Names for the dice pools entered. Seperate each by a coma with no space")
+	flags.StringVar(&opts.tablename, "tablename", opts.tablename, "Names the table. Changes the prompt.")
+}
+
+func (opts *options) bindLog(flags *flag.FlagSet) {
+	flags.StringVar(&opts.logdir, "logdir", opts.logdir, "Directory to keep table logs and prompt history in. Overrides $DICETABLE_LOG_DIR and the config file")
+	flags.BoolVar(&opts.nolog, "nolog", opts.nolog, "Don't write table logs or prompt history")
+}
+
+func (opts *options) bindFormat(flags *flag.FlagSet) {
+	flags.StringVar(&opts.format, "format", opts.format, "How rolls are printed: text, json, csv, markdown or tsv")
+}
+
+func newFlags(name string) *flag.FlagSet {
+	// Make the flag set of a subcommand with usage built from its entry in subcommands
+	flags := flag.NewFlagSet("dicetable "+name, flag.ContinueOnError)
+	flags.Usage = func() {
+		command := subcommands[name]
+		fmt.Fprintf(flags.Output(), "Usage: dicetable %s\n\n%s\n", command.usage, command.summary)
+		fmt.Fprintln(flags.Output(), "\nFlags:")
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+func parseFlags(flags *flag.FlagSet, args []string) (int, bool) {
+	// Parse the flags and return false with the code to exit with if the subcommand shouldn't run.
+	// -h prints the usage and exits cleanly, the flag package has already printed any other error
+	err := flags.Parse(args)
+	if err == flag.ErrHelp {
+		return exitOK, false
+	} else if err != nil {
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, int, bool) {
+	// Parse flags that come before, between or after the arguments, like check 1d20+3 --dc 15
+	var positional []string
+	for {
+		code, ok := parseFlags(flags, args)
+		if !ok {
+			return nil, code, false
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, exitOK, true
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func usage(flags *flag.FlagSet) {
+	out := flags.Output()
+	fmt.Fprintln(out, "Usage: dicetable [flags] [subcommand] [arguments]")
+	fmt.Fprintln(out, "       dicetable [flags] [XdY...]")
+	fmt.Fprintln(out, "\nSubcommands:")
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-9s %s\n", name, subcommands[name].summary)
+	}
+	fmt.Fprintln(out, "\nWithout a subcommand the dice are rolled, or the prompt opens if -i is given.")
+	fmt.Fprintln(out, "Use dicetable help [subcommand] for the flags of a subcommand.")
+	fmt.Fprintln(out, "\nFlags:")
+	flags.PrintDefaults()
+}
+
+func helpFlags(opts *options) *flag.FlagSet {
+	return newFlags("help")
+}
+
+func helpMain(opts *options, args []string) int {
+	if len(args) == 0 {
+		flags := mainFlags(opts)
+		flags.SetOutput(os.Stdout)
+		flags.Usage()
+		return exitOK
+	}
+	command, ok := subcommands[args[0]]
+	if !ok || len(args) > 1 {
+		fmt.Fprintf(os.Stderr, "%s is not a subcommand. Use dicetable help for a list of them.\n", args[0])
+		return exitUsage
+	}
+	flags := command.flags(opts)
+	flags.SetOutput(os.Stdout)
+	flags.Usage()
+	return exitOK
+}
 
+func setup(opts *options) (*tablecommands.Store, error) {
+	// Apply the log settings, the settings of the prompt and open the table store once the flags of the subcommand are known
+	log_config, err := logConfig(opts.config, opts.logdir, opts.nolog)
+	if err != nil {
+		return nil, err
+	}
+	tablecommands.SetLogConfig(log_config)
+	if err := tablecommands.SetConfig(opts.config); err != nil {
+		return nil, err
+	}
+
+	store := openStore(opts.config)
+	tablecommands.SetStore(store)
+	return store, nil
+}
+
+func poolNames(opts *options, dice_args []string) []string {
 	// if no names were entered name each pool XdY
-	if *namesPtr == "" {
-		names = append(names, dice_args...)
-	} else {
-		// Otherwise split names by a coma separator
-		names = strings.Split(*namesPtr, ",")
-	}
-	table, err := dice.ParseTableString(dice_args, names)
-	table.Name = *tablenamePtr
+	if opts.names == "" {
+		return append([]string(nil), dice_args...)
+	}
+	// Otherwise split names by a coma separator
+	return strings.Split(opts.names, ",")
+}
+
+func fail(err error) int {
+	fmt.Fprintln(os.Stderr, err)
+	return exitFailure
+}
+
+func runScript(path string, table *dice.Table, keep_going bool) error {
+	var script io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		script = file
+	}
+
+	_, err := tablecommands.StartLog(table.Name)
 	if err != nil {
-		fmt.Println(err)
-	} else if *interactivePtr {
-		tablecommands.InteractiveLoop(table)
-	} else {
-		table.Roll()
-		for _, pool := range table.Pools {
-			fmt.Printf("%s Their total is %d.\n", pool.Describe(), pool.Total())
+		return err
+	}
+	return tablecommands.RunScript(path, script, table, os.Stdout, keep_going)
+}
+
+func replayLog(path string, table *dice.Table) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = tablecommands.Replay(file, table)
+	return err
+}
+
+func logConfig(file *config.File, logdir string, nolog bool) (tablecommands.LogConfig, error) {
+	// Build the log settings from the defaults, then the config file, then environment variables, then flags.
+	// Each one overrides the ones before it
+	log_config := tablecommands.DefaultLogConfig()
+
+	settings := []struct {
+		key   string
+		apply func(string) error
+	}{
+		{"log.dir", func(v string) error { log_config.Dir = expandHome(v); return nil }},
+		{"log.enabled", func(v string) error {
+			enabled, err := config.ParseBool(v)
+			log_config.Disabled = !enabled
+			return err
+		}},
+		{"log.max_size", func(v string) (err error) { log_config.MaxSize, err = config.ParseSize(v); return err }},
+		{"log.max_files", func(v string) (err error) { log_config.MaxFiles, err = strconv.Atoi(v); return err }},
+		{"log.max_age", func(v string) (err error) { log_config.MaxAge, err = config.ParseDuration(v); return err }},
+		{"log.rotate", func(v string) error {
+			switch v {
+			case "daily":
+				log_config.Daily = true
+			case "size":
+				log_config.Daily = false
+			default:
+				return fmt.Errorf("rotate can be daily or size")
+			}
+			return nil
+		}},
+	}
+	for _, setting := range settings {
+		if value, ok := file.Get(setting.key); ok {
+			if err := setting.apply(value); err != nil {
+				return log_config, fmt.Errorf("%s: %s: %v", file.Path, setting.key, err)
+			}
+		}
+	}
+
+	if dir := os.Getenv("DICETABLE_LOG_DIR"); dir != "" {
+		log_config.Dir = dir
+	}
+	if value := os.Getenv("DICETABLE_LOG"); value != "" {
+		enabled, err := config.ParseBool(value)
+		if err != nil {
+			return log_config, fmt.Errorf("DICETABLE_LOG: %v", err)
 		}
+		log_config.Disabled = !enabled
+	}
+
+	if logdir != "" {
+		log_config.Dir = logdir
+	}
+	if nolog {
+		log_config.Disabled = true
+	}
+	return log_config, nil
+}
+
+func expandHome(path string) string {
+	// Replace a ~ at the start of a path with the home directory
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+func openStore(file *config.File) *tablecommands.Store {
+	// Saved tables go in the directory from $DICETABLE_TABLES_DIR, then tables.dir in the config file,
+	// then the default data directory
+	store := &tablecommands.Store{Dir: tablecommands.DefaultStoreDir()}
+	if dir, ok := file.Get("tables.dir"); ok {
+		store.Dir = expandHome(dir)
+	}
+	if dir := os.Getenv("DICETABLE_TABLES_DIR"); dir != "" {
+		store.Dir = dir
 	}
+	return store
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"dicetable/internal/config"
+	"dicetable/pkg/diceapi"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// The values of the flags of migrate
+type migrateValues struct {
+	database string
+	steps    int
+}
+
+func (values *migrateValues) declare(opts *options) *flag.FlagSet {
+	flags := newFlags("migrate")
+	flags.StringVar(&values.database, "db", databaseURL(opts.config), "The Postgres database to migrate. Defaults to $DATABASE_URL, then database.url in the config file")
+	flags.IntVar(&values.steps, "n", 1, "How many migrations migrate down undoes")
+	return flags
+}
+
+func migrateFlags(opts *options) *flag.FlagSet {
+	return new(migrateValues).declare(opts)
+}
+
+func migrateMain(opts *options, args []string) int {
+	// Change the schema of the diceapi database. migrate up, migrate down [-n steps] or migrate status
+	var values migrateValues
+	flags := values.declare(opts)
+	positional, code, ok := parseInterspersed(flags, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 || (positional[0] != "up" && positional[0] != "down" && positional[0] != "status") {
+		fmt.Fprintln(os.Stderr, "migrate takes one of up, down or status.")
+		return exitUsage
+	}
+	if values.steps < 1 {
+		fmt.Fprintln(os.Stderr, "-n must be at least 1.")
+		return exitUsage
+	}
+	if values.database == "" {
+		fmt.Fprintln(os.Stderr, "There is no database to migrate. Use -db, $DATABASE_URL or database.url in the config file.")
+		return exitUsage
+	}
+
+	conn, err := pgxpool.Connect(context.Background(), values.database)
+	if err != nil {
+		return fail(err)
+	}
+	defer conn.Close()
+
+	switch positional[0] {
+	case "up":
+		applied, err := diceapi.MigrateUp(conn)
+		for _, migration := range applied {
+			fmt.Printf("Applied %d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return fail(err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("The database is already up to date.")
+		}
+	case "down":
+		undone, err := diceapi.MigrateDown(conn, values.steps)
+		for _, migration := range undone {
+			fmt.Printf("Undid %d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return fail(err)
+		}
+		if len(undone) == 0 {
+			fmt.Println("There are no migrations to undo.")
+		}
+	case "status":
+		statuses, err := diceapi.MigrateStatus(conn)
+		for _, status := range statuses {
+			applied := "pending"
+			if !status.Applied.IsZero() {
+				applied = "applied " + status.Applied.Format("2006-01-02 15:04")
+			}
+			fmt.Printf("%d_%s\t%s\n", status.Version, status.Name, applied)
+		}
+		if err != nil {
+			return fail(err)
+		}
+	}
+	return exitOK
+}
+
+func databaseURL(file *config.File) string {
+	// The database comes from $DATABASE_URL, then database.url in the config file
+	if url := os.Getenv("DATABASE_URL"); url != "" {
+		return url
+	}
+	url, _ := file.Get("database.url")
+	return url
+}
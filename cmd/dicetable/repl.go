@@ -0,0 +1,95 @@
+package main
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func replFlags(opts *options) *flag.FlagSet {
+	flags := newFlags("repl")
+	opts.bindTable(flags)
+	flags.StringVar(&opts.script, "script", opts.script, "Run the table commands in a file, or - for stdin, before the prompt opens")
+	flags.BoolVar(&opts.keep_going, "continue", opts.keep_going, "Keep running a script after a command fails")
+	flags.StringVar(&opts.replay, "replay", opts.replay, "Rebuild the table from a log file before anything else")
+	opts.bindLog(flags)
+	opts.bindFormat(flags)
+	return flags
+}
+
+func replMain(opts *options, args []string) int {
+	// Open the prompt for a table
+	flags := replFlags(opts)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	return openTable(opts, flags.Args(), true)
+}
+
+func openTable(opts *options, dice_args []string, prompt bool) int {
+	// Build the table, replay its log and run the script, then open the prompt if it was asked for.
+	// If the script fails without the prompt the exit code says so, with the prompt it opens anyway
+	if err := tablecommands.SetFormat(opts.format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	store, err := setup(opts)
+	if err != nil {
+		return fail(err)
+	}
+	table, err := loadTable(store, opts, dice_args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	if opts.replay != "" {
+		err = replayLog(opts.replay, &table)
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	code := exitOK
+	if opts.script != "" {
+		err = runScript(opts.script, &table, opts.keep_going)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = exitFailure
+		}
+	}
+	if prompt {
+		err = tablecommands.InteractiveLoop(table)
+		if err != nil {
+			return fail(err)
+		}
+		return exitOK
+	}
+	return code
+}
+
+func loadTable(store *tablecommands.Store, opts *options, dice_args []string) (dice.Table, error) {
+	// Start from the table saved under -tablename if there is one and add the pools given on the command line
+	table, err := dice.ParseTableString(dice_args, poolNames(opts, dice_args))
+	if err != nil {
+		return table, err
+	}
+	table.Name = opts.tablename
+	if opts.tablename == "" {
+		return table, nil
+	}
+
+	saved, err := store.Load(opts.tablename)
+	if errors.Is(err, tablecommands.ErrNoTable) {
+		return table, nil
+	} else if err != nil {
+		return table, err
+	}
+	for name, pool := range table.Pools {
+		saved.Pools[name] = pool
+	}
+	return saved, nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"dicetable/internal/report"
+	"dicetable/pkg/dice"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// The values of the flags only roll has
+type rollValues struct {
+	seed    int64
+	repeat  int
+	summary bool
+}
+
+func (values *rollValues) declare(opts *options) *flag.FlagSet {
+	flags := newFlags("roll")
+	opts.bindTable(flags)
+	opts.bindFormat(flags)
+	flags.Int64Var(&values.seed, "seed", 0, "Seed the dice so the same rolls can be made again. 0 picks a new seed")
+	flags.IntVar(&values.repeat, "n", 1, "Roll the pools this many times")
+	flags.IntVar(&values.repeat, "repeat", 1, "The same as -n")
+	flags.BoolVar(&values.summary, "summary", false, "Add up the totals of each pool across the rolls: sum, min, max and mean")
+	return flags
+}
+
+func rollFlags(opts *options) *flag.FlagSet {
+	return new(rollValues).declare(opts)
+}
+
+func rollMain(opts *options, args []string) int {
+	// Roll each pool once and print it. dicetable 3d6 4d8 is the same as dicetable roll 3d6 4d8
+	var values rollValues
+	flags := values.declare(opts)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if err := report.Check(opts.format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if values.repeat < 1 {
+		fmt.Fprintln(os.Stderr, "-n has to be at least 1.")
+		return exitUsage
+	}
+	dice_args := flags.Args()
+	if len(dice_args) == 0 {
+		fmt.Fprintln(os.Stderr, "Nothing to roll. Give the pools as XdY, like dicetable roll 3d6 4d8.")
+		return exitUsage
+	}
+
+	// Each argument is a pool like 3d6, or an expression like 4d6kh3 or 1d20+3 whose dropped dice are marked
+	names := poolNames(opts, dice_args)
+	if len(names) != len(dice_args) {
+		fmt.Fprintln(os.Stderr, "The number of pools and the number of names do not match up.")
+		return exitUsage
+	}
+	pools := make([]*dice.Pool, len(dice_args))
+	expressions := make([]*dice.Expression, len(dice_args))
+	for n, arg := range dice_args {
+		pool, err := dice.ParseDiceString(arg)
+		if err == nil {
+			pools[n] = pool
+			continue
+		}
+		expressions[n], err = dice.ParseExpression(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s is not a pool like 3d6 or a dice expression like 1d20+3.\n", arg)
+			return exitUsage
+		}
+	}
+
+	// Every roll of a batch is seeded from the seed of the batch, so once it is picked the dice are only seeded
+	// from it, even with rng = crypto. Otherwise -seed wouldn't roll the same batch again
+	if values.seed == 0 {
+		values.seed = dice.Reseed()
+	}
+	dice.UseCrypto(false)
+	dice.Seed(values.seed)
+
+	// A single roll without a summary is printed on its own, otherwise as a batch.
+	// Each roll of a batch is seeded from the batch so it can be rolled again on its own
+	if values.repeat == 1 && !values.summary {
+		text, roll := rollOnce(values.seed, opts.tablename, names, dice_args, pools, expressions)
+		if opts.format == report.Text {
+			fmt.Print(text)
+			return exitOK
+		}
+		if err := report.Write(os.Stdout, opts.format, roll); err != nil {
+			return fail(err)
+		}
+		return exitOK
+	}
+
+	batch := report.NewBatch(values.seed, opts.tablename)
+	var text string
+	for n := 0; n < values.repeat; n++ {
+		roll_text, roll := rollOnce(dice.Reseed(), opts.tablename, names, dice_args, pools, expressions)
+		batch.Rolls = append(batch.Rolls, roll)
+		text += fmt.Sprintf("Roll %d:\n%s", n+1, roll_text)
+	}
+	if values.summary {
+		batch.Summary = report.Summarize(batch.Rolls)
+		text += "Summary:\n"
+		for _, pool := range batch.Summary {
+			text += pool.String() + "\n"
+		}
+	}
+
+	if opts.format == report.Text {
+		fmt.Print(text)
+		return exitOK
+	}
+	if err := report.WriteBatch(os.Stdout, opts.format, batch); err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
+
+func rollOnce(seed int64, tablename string, names []string, dice_args []string, pools []*dice.Pool, expressions []*dice.Expression) (string, report.Roll) {
+	// Roll the pools in the order they were given so a seed always rolls the same faces.
+	// Returns the text output and the roll for the other formats
+	roll := report.NewRoll(seed, tablename)
+	var text string
+	for n, name := range names {
+		if pool := pools[n]; pool != nil {
+			pool.Roll()
+			roll.AddPool(name, pool)
+			text += fmt.Sprintf("%s Their total is %d.\n", pool.Describe(), pool.Total())
+			continue
+		}
+		result := expressions[n].Roll()
+		roll.AddExpression(name, result)
+		if name != dice_args[n] {
+			text += name + " - "
+		}
+		text += result.String() + "\n"
+	}
+	return text, roll
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"dicetable/internal/report"
+	"dicetable/pkg/dice"
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func captureStdout(t *testing.T, run func()) string {
+	// Run a subcommand and return what it printed
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+	run()
+	writer.Close()
+	out, _ := io.ReadAll(reader)
+	return string(out)
+}
+
+func TestRollSeedWithCrypto(t *testing.T) {
+	// -seed should roll the same batch again even when rng = crypto takes seeds from the operating system
+	dice.UseCrypto(true)
+	defer dice.UseCrypto(false)
+	opts := &options{format: report.JSON}
+	roll := func() string {
+		dice.UseCrypto(true)
+		return captureStdout(t, func() { rollMain(opts, []string{"-seed", "99", "-n", "3", "3d6", "1d20+2"}) })
+	}
+	first, second := roll(), roll()
+	if first != second {
+		t.Errorf("The same seed should roll the same batch:\n%s\n%s", first, second)
+	}
+
+	// The seed of each roll in the batch rolls just that one again
+	var batch report.Batch
+	if err := json.Unmarshal([]byte(first), &batch); err != nil || len(batch.Rolls) != 3 {
+		t.Fatalf("roll should print a batch of 3 rolls, got %v\n%s", err, first)
+	}
+	var again report.Roll
+	out := captureStdout(t, func() {
+		dice.UseCrypto(true)
+		rollMain(opts, []string{"-seed", strconv.FormatInt(batch.Rolls[2].Seed, 10), "3d6", "1d20+2"})
+	})
+	if err := json.Unmarshal([]byte(out), &again); err != nil || !reflect.DeepEqual(again.Pools, batch.Rolls[2].Pools) {
+		t.Errorf("The seed of the third roll should roll %+v again, got %+v %v", batch.Rolls[2].Pools, again.Pools, err)
+	}
+}
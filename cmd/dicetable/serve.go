@@ -0,0 +1,179 @@
+package main
+
+import (
+	"dicetable/internal/tablecommands"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// The values of the flags of serve
+type serveValues struct {
+	addr string
+}
+
+func (values *serveValues) declare(opts *options) *flag.FlagSet {
+	flags := newFlags("serve")
+	flags.StringVar(&values.addr, "addr", "localhost:8080", "The address to listen on")
+	return flags
+}
+
+func serveFlags(opts *options) *flag.FlagSet {
+	return new(serveValues).declare(opts)
+}
+
+func serveMain(opts *options, args []string) int {
+	// Serve the saved tables over HTTP.
+	// GET /tables lists them, GET /tables/[name] returns one, and POST /tables/[name]/commands runs a command
+	// against it and saves it again
+	var values serveValues
+	flags := values.declare(opts)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "serve doesn't take any arguments.")
+		return exitUsage
+	}
+	// Commands from the server aren't logged, the saved tables are their record
+	opts.nolog = true
+	store, err := setup(opts)
+	if err != nil {
+		return fail(err)
+	}
+
+	server := &tableServer{store: store}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tables", server.list)
+	mux.HandleFunc("/tables/", server.table)
+	fmt.Fprintf(os.Stderr, "Serving tables from %s on http://%s\n", store.Dir, values.addr)
+	err = http.ListenAndServe(values.addr, mux)
+	return fail(err)
+}
+
+type tableServer struct {
+	store *tablecommands.Store
+	// Commands load, change and save a whole table so only one runs at a time
+	mutex sync.Mutex
+}
+
+// The commands POST /tables/[name]/commands will run. Commands that read or write files, like source, replay,
+// config and table, and aliases, which could stand for them, are refused
+var servedCommands = map[string]bool{
+	"help": true, "roll": true, "r": true, "add": true, "subtract": true, "view": true, "clear": true, "set": true,
+	"copy": true, "move": true, "capture": true, "history": true, "stats": true, "rename": true, "describe": true,
+	"tag": true, "untag": true, "reroll": true, "sort": true, "counter": true, "init": true, "next": true,
+	"prev": true, "delay": true,
+}
+
+// What running a command returns
+type commandResult struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (server *tableServer) list(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET is allowed.", http.StatusMethodNotAllowed)
+		return
+	}
+	server.mutex.Lock()
+	files, err := server.store.List()
+	server.mutex.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if files == nil {
+		files = []tablecommands.TableFile{}
+	}
+	writeJSON(w, http.StatusOK, files)
+}
+
+func (server *tableServer) table(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/tables/")
+	name := strings.TrimSuffix(path, "/commands")
+	switch {
+	case name == "" || strings.Contains(name, "/"):
+		http.NotFound(w, r)
+	case path == name && r.Method == http.MethodGet:
+		server.get(w, name)
+	case path != name && r.Method == http.MethodPost:
+		server.command(w, r, name)
+	default:
+		http.Error(w, "Use GET /tables/[name] or POST /tables/[name]/commands.", http.StatusMethodNotAllowed)
+	}
+}
+
+func (server *tableServer) get(w http.ResponseWriter, name string) {
+	server.mutex.Lock()
+	table, err := server.store.Load(name)
+	server.mutex.Unlock()
+	if errors.Is(err, tablecommands.ErrNoTable) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, tablecommands.NewTableFile(&table))
+}
+
+func (server *tableServer) command(w http.ResponseWriter, r *http.Request, name string) {
+	// The body is {"command": "roll pool strength"}. A table that hasn't been saved yet starts empty.
+	// Only JSON is accepted so a web page can't post a form to the server from another site
+	media_type, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || media_type != "application/json" {
+		http.Error(w, "The body must be sent with Content-Type: application/json.", http.StatusUnsupportedMediaType)
+		return
+	}
+	var body struct {
+		Command string `json:"command"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("The body could not be read: %v", err), http.StatusBadRequest)
+		return
+	}
+	words, err := tablecommands.Tokenize(body.Command)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, commandResult{Error: err.Error()})
+		return
+	}
+	if len(words) == 0 || !servedCommands[words[0]] {
+		http.Error(w, fmt.Sprintf("%q can't be run over HTTP.", body.Command), http.StatusForbidden)
+		return
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	table, err := server.store.Load(name)
+	if err != nil && !errors.Is(err, tablecommands.ErrNoTable) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	output, err := tablecommands.RunCommand(body.Command, &table)
+	result := commandResult{Output: output}
+	status := http.StatusOK
+	if err != nil {
+		result.Error = err.Error()
+		status = http.StatusUnprocessableEntity
+	}
+	// Save even if the command failed, some of its arguments may have worked
+	if save_err := server.store.Save(&table); save_err != nil {
+		http.Error(w, save_err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, status, result)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"dicetable/internal/tablecommands"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func postCommand(server *tableServer, content_type string, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/tables/camp/commands", strings.NewReader(body))
+	if content_type != "" {
+		request.Header.Set("Content-Type", content_type)
+	}
+	recorder := httptest.NewRecorder()
+	server.table(recorder, request)
+	return recorder
+}
+
+func TestServeCommands(t *testing.T) {
+	// Only table commands sent as JSON should run, and nothing should be able to read files
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret")
+	os.WriteFile(secret, []byte("add pool hidden:1d6\n"), 0600)
+	server := &tableServer{store: &tablecommands.Store{Dir: filepath.Join(dir, "tables")}}
+
+	if recorder := postCommand(server, "application/json", `{"command": "add pool strength:4d6"}`); recorder.Code != http.StatusOK {
+		t.Errorf("add should have run, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := postCommand(server, "application/json; charset=utf-8", `{"command": "roll pool strength"}`); recorder.Code != http.StatusOK {
+		t.Errorf("roll should have run with a charset, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := postCommand(server, "text/plain", `{"command": "roll pool strength"}`); recorder.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("A body that isn't JSON should be refused, got %d", recorder.Code)
+	}
+	if recorder := postCommand(server, "", `{"command": "roll pool strength"}`); recorder.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("A body without a Content-Type should be refused, got %d", recorder.Code)
+	}
+	for _, command := range []string{"source " + secret + " --continue", "replay " + secret, "config save", "table save other", "alias x source " + secret, "2d6"} {
+		recorder := postCommand(server, "application/json", `{"command": "`+command+`"}`)
+		if recorder.Code != http.StatusForbidden {
+			t.Errorf("%q should have been refused, got %d %s", command, recorder.Code, recorder.Body.String())
+		}
+	}
+
+	table, err := server.store.Load("camp")
+	if err != nil || table.Pools["strength"] == nil || table.Pools["hidden"] != nil {
+		t.Errorf("Only the allowed commands should have changed the saved table, got %v %v", table.Pools, err)
+	}
+}
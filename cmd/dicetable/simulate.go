@@ -0,0 +1,97 @@
+package main
+
+import (
+	"dicetable/pkg/dice"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// The widest a bar of the histogram is drawn
+const histogramWidth = 50
+
+// The values of the flags of simulate
+type simulateValues struct {
+	times int
+	seed  int64
+	dc    int
+}
+
+func (values *simulateValues) declare(opts *options) *flag.FlagSet {
+	flags := newFlags("simulate")
+	flags.IntVar(&values.times, "n", 10000, "How many times to roll the expression")
+	flags.Int64Var(&values.seed, "seed", 0, "Seed the dice so the same rolls can be made again. 0 picks a new seed")
+	flags.IntVar(&values.dc, "dc", 0, "Also show the chance of rolling at least this total")
+	return flags
+}
+
+func simulateFlags(opts *options) *flag.FlagSet {
+	return new(simulateValues).declare(opts)
+}
+
+func simulateMain(opts *options, args []string) int {
+	// Roll an expression many times and show the mean, spread and a histogram of the totals
+	var values simulateValues
+	flags := values.declare(opts)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Give a dice expression to simulate, like dicetable simulate 2d6+3.")
+		return exitUsage
+	}
+	if values.times < 1 {
+		fmt.Fprintln(os.Stderr, "-n has to be at least 1.")
+		return exitUsage
+	}
+	expression, err := dice.ParseExpression(strings.Join(flags.Args(), " "))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if values.seed != 0 {
+		dice.Seed(values.seed)
+	}
+
+	counts := make(map[int]int)
+	sum := 0.0
+	squares := 0.0
+	for n := 0; n < values.times; n++ {
+		total := expression.Roll().Total
+		counts[total]++
+		sum += float64(total)
+		squares += float64(total) * float64(total)
+	}
+	totals := make([]int, 0, len(counts))
+	most := 0
+	for total, count := range counts {
+		totals = append(totals, total)
+		if count > most {
+			most = count
+		}
+	}
+	sort.Ints(totals)
+
+	mean := sum / float64(values.times)
+	deviation := math.Sqrt(math.Max(squares/float64(values.times)-mean*mean, 0))
+	fmt.Printf("%s rolled %d times\n", expression.Text, values.times)
+	fmt.Printf("Mean: %.2f Standard deviation: %.2f Lowest: %d Highest: %d\n", mean, deviation, totals[0], totals[len(totals)-1])
+	if values.dc != 0 {
+		hits := 0
+		for total, count := range counts {
+			if total >= values.dc {
+				hits += count
+			}
+		}
+		fmt.Printf("Chance of %d or more: %.1f%%\n", values.dc, 100*float64(hits)/float64(values.times))
+	}
+	for _, total := range totals {
+		count := counts[total]
+		bar := strings.Repeat("#", (count*histogramWidth+most-1)/most)
+		fmt.Printf("%5d %6.2f%% %s\n", total, 100*float64(count)/float64(values.times), bar)
+	}
+	return exitOK
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func statsFlags(opts *options) *flag.FlagSet {
+	flags := newFlags("stats")
+	flags.StringVar(&opts.tablename, "tablename", opts.tablename, "The saved table to show the stats of")
+	flags.StringVar(&opts.replay, "replay", opts.replay, "Rebuild the table from a log file instead of loading it")
+	return flags
+}
+
+func statsMain(opts *options, args []string) int {
+	// Show the stats of a table saved with table save, or rebuilt from a log with -replay
+	flags := statsFlags(opts)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if opts.tablename == "" && opts.replay == "" {
+		fmt.Fprintln(os.Stderr, "Give the table to show with -tablename or a log to rebuild it from with -replay.")
+		return exitUsage
+	}
+
+	// Logging is never started so looking at the stats doesn't add to the table's log
+	opts.nolog = true
+	store, err := setup(opts)
+	if err != nil {
+		return fail(err)
+	}
+	var table dice.Table
+	if opts.replay != "" {
+		table, _ = dice.CreateTable(nil, nil)
+		table.Name = opts.tablename
+		err = replayLog(opts.replay, &table)
+	} else {
+		table, err = store.Load(opts.tablename)
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	command := "stats"
+	if flags.NArg() > 0 {
+		command = "stats pool " + joinArgs(flags.Args())
+	}
+	output, err := tablecommands.RunCommand(command, &table)
+	fmt.Print(output)
+	if err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
+
+func joinArgs(args []string) string {
+	// Join arguments into a command line, quoting any that would be split or read differently
+	quoted := make([]string, len(args))
+	for n, arg := range args {
+		quoted[n] = arg
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\#") {
+			quoted[n] = strconv.Quote(arg)
+		}
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func tableMain(opts *options, args []string) int {
+	// Work with the saved tables. table list, table load [name], table save [flags] [name] [XdY...]
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		flags := tableFlags(opts)
+		flags.Usage()
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	switch args[0] {
+	case "list":
+		return listTables(opts, args[1:])
+	case "load":
+		return printTable(opts, args[1:])
+	case "save":
+		return saveTable(opts, args[1:])
+	}
+	fmt.Fprintf(os.Stderr, "%s is not a table subcommand. Use table list, table load or table save.\n", args[0])
+	return exitUsage
+}
+
+func tableFlags(opts *options) *flag.FlagSet {
+	return newFlags("table")
+}
+
+func tableActionFlags(opts *options, action string) *flag.FlagSet {
+	// table list and load have no flags of their own, table save has -names and -replay
+	flags := newFlags("table")
+	if action == "save" {
+		flags.StringVar(&opts.names, "names", opts.names, "Names for the dice pools entered. Seperate each by a coma with no space")
+		flags.StringVar(&opts.replay, "replay", opts.replay, "Rebuild the table from a log file before the pools are added")
+	}
+	return flags
+}
+
+func listTables(opts *options, args []string) int {
+	flags := tableActionFlags(opts, "list")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "table list doesn't take any arguments.")
+		return exitUsage
+	}
+	store, err := setup(opts)
+	if err != nil {
+		return fail(err)
+	}
+	files, err := store.List()
+	if err != nil {
+		return fail(err)
+	}
+	for _, file := range files {
+		fmt.Printf("%s\t%d pools\tsaved %s\n", file.Name, len(file.Pools), file.Saved.Format("2006-01-02 15:04"))
+	}
+	return exitOK
+}
+
+func printTable(opts *options, args []string) int {
+	flags := tableActionFlags(opts, "load")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "table load takes the name of one saved table.")
+		return exitUsage
+	}
+	store, err := setup(opts)
+	if err != nil {
+		return fail(err)
+	}
+	table, err := store.Load(flags.Arg(0))
+	if err != nil {
+		return fail(err)
+	}
+	output, err := tablecommands.RunCommand("view table", &table)
+	fmt.Print(output)
+	if err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
+
+func saveTable(opts *options, args []string) int {
+	// Save a new table with the pools given, or one rebuilt from a log with -replay. Replaces any saved table with the name
+	flags := tableActionFlags(opts, "save")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "table save needs a name for the table.")
+		return exitUsage
+	}
+	name := flags.Arg(0)
+	dice_args := flags.Args()[1:]
+
+	table, err := dice.ParseTableString(dice_args, poolNames(opts, dice_args))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	table.Name = name
+	store, err := setup(opts)
+	if err != nil {
+		return fail(err)
+	}
+	if opts.replay != "" {
+		replayed, _ := dice.CreateTable(nil, nil)
+		replayed.Name = name
+		err = replayLog(opts.replay, &replayed)
+		if err != nil {
+			return fail(err)
+		}
+		for pool_name, pool := range table.Pools {
+			replayed.Pools[pool_name] = pool
+		}
+		table = replayed
+	}
+
+	err = store.Save(&table)
+	if err != nil {
+		return fail(err)
+	}
+	fmt.Printf("Saved table %s with %d pools.\n", name, len(table.Pools))
+	return exitOK
+}
@@ -2,4 +2,8 @@ module dicetable
 
 go 1.16
 
-require github.com/jackc/pgx/v4 v4.13.0 // indirect
+require (
+	github.com/jackc/pgconn v1.10.0
+	github.com/jackc/pgx/v4 v4.13.0
+	golang.org/x/term v0.0.0-20210220032956-6a3ed077a48d
+)
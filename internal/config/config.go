@@ -0,0 +1,164 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A File is a config file of key = value lines. Blank lines and lines starting with # are ignored.
+// Keys can be grouped with dots, like log.dir
+type File struct {
+	Path   string
+	values map[string]string
+}
+
+func Path() (string, error) {
+	// Return where the config file is kept. $XDG_CONFIG_HOME/dicetable/config, or ~/.config/dicetable/config
+	// if XDG_CONFIG_HOME is not set. $DICETABLE_CONFIG overrides both
+	if path := os.Getenv("DICETABLE_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "dicetable", "config"), nil
+}
+
+func Load(path string) (*File, error) {
+	// Read a config file. A file that doesn't exist is the same as an empty one
+	file := &File{Path: path, values: make(map[string]string)}
+	opened, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return file, nil
+	} else if err != nil {
+		return file, err
+	}
+	defer opened.Close()
+
+	scanner := bufio.NewScanner(opened)
+	line_number := 0
+	for scanner.Scan() {
+		line_number++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return file, fmt.Errorf("%s:%d: settings need to be in the format key = value", path, line_number)
+		}
+		key := strings.TrimSpace(line[:i])
+		if key == "" {
+			return file, fmt.Errorf("%s:%d: setting has no name", path, line_number)
+		}
+		file.values[key] = unquote(strings.TrimSpace(line[i+1:]))
+	}
+	return file, scanner.Err()
+}
+
+func unquote(value string) string {
+	// Values can be wrapped in double quotes to keep spaces at their start or end
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+	}
+	return value
+}
+
+func (file *File) Get(key string) (string, bool) {
+	value, ok := file.values[key]
+	return value, ok
+}
+
+func (file *File) Set(key string, value string) {
+	file.values[key] = value
+}
+
+func (file *File) Unset(key string) {
+	delete(file.values, key)
+}
+
+func (file *File) Keys() []string {
+	// Return the keys that are set, sorted
+	keys := make([]string, 0, len(file.values))
+	for key := range file.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (file *File) Save() error {
+	// Write the settings back to the file, creating the directory it is in if needed
+	err := os.MkdirAll(filepath.Dir(file.Path), 0700)
+	if err != nil {
+		return err
+	}
+	var contents strings.Builder
+	for _, key := range file.Keys() {
+		value := file.values[key]
+		if value != strings.TrimSpace(value) || strings.HasPrefix(value, `"`) {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&contents, "%s = %s\n", key, value)
+	}
+	return os.WriteFile(file.Path, []byte(contents.String()), 0600)
+}
+
+func ParseSize(value string) (int64, error) {
+	// Read a size in bytes with an optional unit, like 512, 64KB, 10MB or 1GB
+	units := []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1}}
+
+	upper := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(upper, unit.suffix) {
+			upper = strings.TrimSpace(strings.TrimSuffix(upper, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s is not a size. Use a number of bytes like 512, 64KB or 10MB", value)
+	}
+	return n * multiplier, nil
+}
+
+func ParseDuration(value string) (time.Duration, error) {
+	// Read a duration. On top of what time.ParseDuration accepts, a number of days can be given like 30d
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("%s is not a number of days", value)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+func ParseBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "on", "true", "yes", "y", "1":
+		return true, nil
+	case "off", "false", "no", "n", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("%s is not on or off", value)
+}
@@ -0,0 +1,75 @@
+package config_test
+
+import (
+	"dicetable/internal/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadAndSave(t *testing.T) {
+	// Load should read key = value lines and skip comments, Save should write them back
+	path := filepath.Join(t.TempDir(), "dicetable", "config")
+	os.MkdirAll(filepath.Dir(path), 0700)
+	os.WriteFile(path, []byte("# logs\nlog.dir = /tmp/dice\n\nprompt = \"  {table}> \"\n"), 0600)
+
+	file, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	if dir, _ := file.Get("log.dir"); dir != "/tmp/dice" {
+		t.Errorf("log.dir should be /tmp/dice, instead it is %q", dir)
+	}
+	if prompt, _ := file.Get("prompt"); prompt != "  {table}> " {
+		t.Errorf("Quoted values should keep their spaces, instead prompt is %q", prompt)
+	}
+
+	file.Set("log.enabled", "off")
+	file.Unset("log.dir")
+	if err := file.Save(); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+	saved, _ := config.Load(path)
+	if _, ok := saved.Get("log.dir"); ok {
+		t.Errorf("log.dir should have been removed from the file")
+	}
+	if prompt, _ := saved.Get("prompt"); prompt != "  {table}> " {
+		t.Errorf("Saving should keep the spaces in prompt, instead it is %q", prompt)
+	}
+	if enabled, _ := saved.Get("log.enabled"); enabled != "off" {
+		t.Errorf("log.enabled should have been saved as off, instead it is %q", enabled)
+	}
+
+	// A missing file is the same as an empty one and a line without = is an error
+	if _, err := config.Load(filepath.Join(t.TempDir(), "missing")); err != nil {
+		t.Errorf("Loading a missing file should not return an error: %v", err)
+	}
+	os.WriteFile(path, []byte("log.dir\n"), 0600)
+	if _, err := config.Load(path); err == nil {
+		t.Errorf("A line without = should return an error")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{"512": 512, "64KB": 64 << 10, "10MB": 10 << 20, "1g": 1 << 30, "0": 0}
+	for value, expected := range cases {
+		size, err := config.ParseSize(value)
+		if err != nil || size != expected {
+			t.Errorf("ParseSize(%q) returned %d %v, expected %d", value, size, err, expected)
+		}
+	}
+	if _, err := config.ParseSize("ten"); err == nil {
+		t.Errorf("ParseSize should not accept ten")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]time.Duration{"30d": 30 * 24 * time.Hour, "12h": 12 * time.Hour}
+	for value, expected := range cases {
+		duration, err := config.ParseDuration(value)
+		if err != nil || duration != expected {
+			t.Errorf("ParseDuration(%q) returned %v %v, expected %v", value, duration, err, expected)
+		}
+	}
+}
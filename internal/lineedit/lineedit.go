@@ -0,0 +1,504 @@
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+// ErrInterrupted is returned by ReadLine when the user presses Ctrl-C
+var ErrInterrupted = errors.New("interrupted")
+
+// A Completer is given the text of the line up to the cursor. It returns the position of the first
+// rune of the word being completed and the words that could replace it.
+type Completer func(line string) (int, []string)
+
+type Editor struct {
+	Complete    Completer
+	HistoryFile string
+	HistorySize int
+	// Editing turns on key handling. It is set by New when the input is a terminal.
+	Editing bool
+
+	in       *bufio.Reader
+	out      io.Writer
+	fd       int
+	terminal bool
+	history  []string
+}
+
+func New(in io.Reader, out io.Writer) *Editor {
+	// Create a line editor reading from in and drawing to out.
+	// If in is a terminal it is put into raw mode while a line is being read.
+	editor := &Editor{in: bufio.NewReader(in), out: out, HistorySize: 1000}
+	if file, ok := in.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		editor.fd = int(file.Fd())
+		editor.terminal = true
+		editor.Editing = true
+	}
+	return editor
+}
+
+func (editor *Editor) LoadHistory() error {
+	// Read previous lines from the history file. A history file that doesn't exist yet is not an error
+	if editor.HistoryFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(editor.HistoryFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	editor.history = editor.history[:0]
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			editor.history = append(editor.history, line)
+		}
+	}
+
+	// Rewrite the file if it has grown past the history size so it doesn't grow forever
+	if editor.trim() {
+		return os.WriteFile(editor.HistoryFile, []byte(strings.Join(editor.history, "\n")+"\n"), 0600)
+	}
+	return nil
+}
+
+func (editor *Editor) AddHistory(line string) error {
+	// Add a line to the history and append it to the history file.
+	// Blank lines and lines repeating the previous line are skipped
+	if strings.TrimSpace(line) == "" || strings.ContainsAny(line, "\r\n") {
+		return nil
+	}
+	if len(editor.history) > 0 && editor.history[len(editor.history)-1] == line {
+		return nil
+	}
+	editor.history = append(editor.history, line)
+	editor.trim()
+
+	if editor.HistoryFile == "" {
+		return nil
+	}
+	file, err := os.OpenFile(editor.HistoryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(file, line)
+	if close_err := file.Close(); err == nil {
+		err = close_err
+	}
+	return err
+}
+
+func (editor *Editor) History() []string {
+	// Return a copy of the history, oldest line first
+	history := make([]string, len(editor.history))
+	copy(history, editor.history)
+	return history
+}
+
+func (editor *Editor) trim() bool {
+	if editor.HistorySize > 0 && len(editor.history) > editor.HistorySize {
+		editor.history = editor.history[len(editor.history)-editor.HistorySize:]
+		return true
+	}
+	return false
+}
+
+func (editor *Editor) ReadLine(prompt string) (string, error) {
+	// Display the prompt and read a line. Returns io.EOF when the input ends or Ctrl-D is pressed on an empty line
+	if !editor.Editing {
+		fmt.Fprint(editor.out, prompt)
+		line, err := editor.in.ReadString('\n')
+		if err != nil && !(err == io.EOF && line != "") {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	if editor.terminal {
+		state, err := term.MakeRaw(editor.fd)
+		if err != nil {
+			return "", err
+		}
+		defer term.Restore(editor.fd, state)
+	}
+
+	state := &lineState{editor: editor, prompt: prompt, history_pos: len(editor.history)}
+	return state.run()
+}
+
+func ctrl(r rune) rune {
+	return r & 0x1f
+}
+
+// lineState holds the line being edited by a single call to ReadLine
+type lineState struct {
+	editor      *Editor
+	prompt      string
+	line        []rune
+	pos         int
+	history_pos int
+	saved       []rune
+	tabbed      bool
+}
+
+func (state *lineState) run() (string, error) {
+	state.refresh()
+	for {
+		r, _, err := state.editor.in.ReadRune()
+		if err != nil {
+			if err == io.EOF && len(state.line) > 0 {
+				state.write("\r\n")
+				return string(state.line), nil
+			}
+			return "", err
+		}
+
+		tabbed := false
+		switch r {
+		case '\r', '\n':
+			state.write("\r\n")
+			return string(state.line), nil
+		case ctrl('C'):
+			state.write("^C\r\n")
+			return "", ErrInterrupted
+		case ctrl('D'):
+			if len(state.line) == 0 {
+				state.write("\r\n")
+				return "", io.EOF
+			}
+			state.deleteForward()
+		case ctrl('A'):
+			state.pos = 0
+		case ctrl('E'):
+			state.pos = len(state.line)
+		case ctrl('B'):
+			state.moveLeft()
+		case ctrl('F'):
+			state.moveRight()
+		case ctrl('H'), 127:
+			state.deleteBackward()
+		case ctrl('K'):
+			state.line = state.line[:state.pos]
+		case ctrl('U'):
+			state.line = append([]rune{}, state.line[state.pos:]...)
+			state.pos = 0
+		case ctrl('W'):
+			state.deleteWordBackward()
+		case ctrl('L'):
+			state.write("\x1b[H\x1b[2J")
+		case ctrl('P'):
+			state.historyPrevious()
+		case ctrl('N'):
+			state.historyNext()
+		case ctrl('R'):
+			accepted, err := state.search()
+			if err != nil {
+				return "", err
+			}
+			if accepted {
+				state.write("\r\n")
+				return string(state.line), nil
+			}
+		case '\t':
+			state.complete()
+			tabbed = true
+		case 27:
+			if err := state.escape(); err != nil {
+				return "", err
+			}
+		default:
+			if unicode.IsPrint(r) {
+				state.insert(r)
+			}
+		}
+		state.tabbed = tabbed
+		state.refresh()
+	}
+}
+
+func (state *lineState) write(s string) {
+	fmt.Fprint(state.editor.out, s)
+}
+
+func (state *lineState) refresh() {
+	// Redraw the prompt and the line then move the cursor back to where it is in the line
+	state.write("\r" + state.prompt + string(state.line) + "\x1b[K")
+	if back := len(state.line) - state.pos; back > 0 {
+		state.write(fmt.Sprintf("\x1b[%dD", back))
+	}
+}
+
+func (state *lineState) escape() error {
+	// Read the rest of an escape sequence and handle the keys that send one
+	r, _, err := state.editor.in.ReadRune()
+	if err != nil {
+		return err
+	}
+	switch r {
+	case 'b':
+		state.moveWordLeft()
+	case 'f':
+		state.moveWordRight()
+	case 127:
+		state.deleteWordBackward()
+	case '[', 'O':
+		seq := string(r)
+		for {
+			r, _, err = state.editor.in.ReadRune()
+			if err != nil {
+				return err
+			}
+			seq += string(r)
+			if r >= 0x40 && r <= 0x7e {
+				break
+			}
+		}
+		switch seq {
+		case "[A", "OA":
+			state.historyPrevious()
+		case "[B", "OB":
+			state.historyNext()
+		case "[C", "OC":
+			state.moveRight()
+		case "[D", "OD":
+			state.moveLeft()
+		case "[H", "OH", "[1~", "[7~":
+			state.pos = 0
+		case "[F", "OF", "[4~", "[8~":
+			state.pos = len(state.line)
+		case "[3~":
+			state.deleteForward()
+		case "[1;5C", "[1;3C":
+			state.moveWordRight()
+		case "[1;5D", "[1;3D":
+			state.moveWordLeft()
+		}
+	}
+	return nil
+}
+
+func (state *lineState) insert(r rune) {
+	state.line = append(state.line, 0)
+	copy(state.line[state.pos+1:], state.line[state.pos:])
+	state.line[state.pos] = r
+	state.pos++
+}
+
+func (state *lineState) replace(start int, end int, text []rune) {
+	// Replace the runes from start to end with text and put the cursor after it
+	line := append([]rune{}, state.line[:start]...)
+	line = append(line, text...)
+	state.line = append(line, state.line[end:]...)
+	state.pos = start + len(text)
+}
+
+func (state *lineState) moveLeft() {
+	if state.pos > 0 {
+		state.pos--
+	}
+}
+
+func (state *lineState) moveRight() {
+	if state.pos < len(state.line) {
+		state.pos++
+	}
+}
+
+func (state *lineState) wordStart() int {
+	// Find the start of the word before the cursor
+	n := state.pos
+	for n > 0 && unicode.IsSpace(state.line[n-1]) {
+		n--
+	}
+	for n > 0 && !unicode.IsSpace(state.line[n-1]) {
+		n--
+	}
+	return n
+}
+
+func (state *lineState) moveWordLeft() {
+	state.pos = state.wordStart()
+}
+
+func (state *lineState) moveWordRight() {
+	n := state.pos
+	for n < len(state.line) && unicode.IsSpace(state.line[n]) {
+		n++
+	}
+	for n < len(state.line) && !unicode.IsSpace(state.line[n]) {
+		n++
+	}
+	state.pos = n
+}
+
+func (state *lineState) deleteBackward() {
+	if state.pos > 0 {
+		state.replace(state.pos-1, state.pos, nil)
+	}
+}
+
+func (state *lineState) deleteForward() {
+	if state.pos < len(state.line) {
+		state.replace(state.pos, state.pos+1, nil)
+	}
+}
+
+func (state *lineState) deleteWordBackward() {
+	state.replace(state.wordStart(), state.pos, nil)
+}
+
+func (state *lineState) historyPrevious() {
+	history := state.editor.history
+	if state.history_pos == 0 {
+		return
+	}
+	// Keep the line being typed so coming back down the history restores it
+	if state.history_pos == len(history) {
+		state.saved = state.line
+	}
+	state.history_pos--
+	state.line = []rune(history[state.history_pos])
+	state.pos = len(state.line)
+}
+
+func (state *lineState) historyNext() {
+	history := state.editor.history
+	if state.history_pos >= len(history) {
+		return
+	}
+	state.history_pos++
+	if state.history_pos == len(history) {
+		state.line = state.saved
+	} else {
+		state.line = []rune(history[state.history_pos])
+	}
+	state.pos = len(state.line)
+}
+
+func (state *lineState) search() (bool, error) {
+	// Search backwards through the history for lines containing what has been typed.
+	// Ctrl-R moves to the next older match, Enter runs the match, Ctrl-G cancels,
+	// and any other key keeps the match on the line for editing.
+	// Returns true if the match should be run.
+	history := state.editor.history
+	var query []rune
+	match := -1
+
+	find := func(from int) int {
+		for n := from; n >= 0; n-- {
+			if n < len(history) && strings.Contains(history[n], string(query)) {
+				return n
+			}
+		}
+		return -1
+	}
+
+	for {
+		label := "reverse-i-search"
+		found := ""
+		if match >= 0 {
+			found = history[match]
+		} else if len(query) > 0 {
+			label = "failing " + label
+		}
+		state.write(fmt.Sprintf("\r(%s)`%s': %s\x1b[K", label, string(query), found))
+
+		r, _, err := state.editor.in.ReadRune()
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case r == ctrl('R'):
+			if match > 0 {
+				if older := find(match - 1); older >= 0 {
+					match = older
+				}
+			} else if match < 0 && len(query) == 0 {
+				match = find(len(history) - 1)
+			}
+		case r == ctrl('G') || r == ctrl('C'):
+			return false, nil
+		case r == ctrl('H') || r == 127:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				match = find(len(history) - 1)
+			}
+		case r == '\r' || r == '\n':
+			if match >= 0 {
+				state.line = []rune(history[match])
+				state.pos = len(state.line)
+			}
+			return true, nil
+		case unicode.IsPrint(r):
+			query = append(query, r)
+			// Keep the current match if it still matches, otherwise look further back
+			if match < 0 {
+				match = find(len(history) - 1)
+			} else {
+				match = find(match)
+			}
+		default:
+			if match >= 0 {
+				state.line = []rune(history[match])
+				state.pos = len(state.line)
+			}
+			if r == 27 {
+				return false, state.escape()
+			}
+			return false, nil
+		}
+	}
+}
+
+func (state *lineState) complete() {
+	// Complete the word before the cursor. A single match is filled in, several matches are filled in up to
+	// what they have in common and pressing tab a second time lists them
+	if state.editor.Complete == nil {
+		return
+	}
+	start, candidates := state.editor.Complete(string(state.line[:state.pos]))
+	if len(candidates) == 0 || start < 0 || start > state.pos {
+		state.write("\a")
+		return
+	}
+
+	var text []rune
+	if len(candidates) == 1 {
+		text = append([]rune(candidates[0]), ' ')
+	} else {
+		text = commonPrefix(candidates)
+	}
+
+	if len(text) > state.pos-start || (len(candidates) == 1 && string(text) != string(state.line[start:state.pos])) {
+		state.replace(start, state.pos, text)
+	} else if state.tabbed {
+		sorted := append([]string{}, candidates...)
+		sort.Strings(sorted)
+		state.write("\r\n" + strings.Join(sorted, "  ") + "\r\n")
+	} else {
+		state.write("\a")
+	}
+}
+
+func commonPrefix(words []string) []rune {
+	prefix := []rune(words[0])
+	for _, word := range words[1:] {
+		runes := []rune(word)
+		n := 0
+		for n < len(prefix) && n < len(runes) && prefix[n] == runes[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return prefix
+}
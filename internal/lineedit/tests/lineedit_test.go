@@ -0,0 +1,116 @@
+package lineedit_test
+
+import (
+	"dicetable/internal/lineedit"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newEditor(input string) *lineedit.Editor {
+	// Create an editor with key handling turned on reading from a string
+	editor := lineedit.New(strings.NewReader(input), ioutil.Discard)
+	editor.Editing = true
+	return editor
+}
+
+func TestReadLineEditing(t *testing.T) {
+	// Cursor movement and deletion keys should edit the line before it is returned
+	cases := map[string]string{
+		"roll table\r": "roll table",
+		"rol table\x1b[D\x1b[D\x1b[D\x1b[D\x1b[D\x1b[Dl\r": "roll table",
+		"roll tablex\x7f\r":                 "roll table",
+		"view table\x01roll \x0b\r":         "roll ",
+		"roll pool strength\x17\x17table\r": "roll table",
+		"xroll table\x01\x1b[3~\r":          "roll table",
+		"table\x01roll \x05\r":              "roll table",
+	}
+	for input, expected := range cases {
+		line, err := newEditor(input).ReadLine(":> ")
+		if err != nil {
+			t.Errorf("ReadLine(%q) returned an error: %v", input, err)
+		}
+		if line != expected {
+			t.Errorf("ReadLine(%q) returned %q, expected %q", input, line, expected)
+		}
+	}
+
+	// Ctrl-D on an empty line ends the input
+	_, err := newEditor("\x04").ReadLine(":> ")
+	if err != io.EOF {
+		t.Errorf("Ctrl-D on an empty line should return io.EOF, instead returned %v", err)
+	}
+
+	// Ctrl-C abandons the line
+	_, err = newEditor("roll\x03").ReadLine(":> ")
+	if err != lineedit.ErrInterrupted {
+		t.Errorf("Ctrl-C should return ErrInterrupted, instead returned %v", err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	// The up and down arrows and Ctrl-R should bring back lines from the history file
+	history_file := filepath.Join(t.TempDir(), "history")
+	editor := newEditor("\x1b[A\x1b[A\r\x1b[A\x1b[B\r\x12pool\r")
+	editor.HistoryFile = history_file
+	editor.AddHistory("roll pool strength")
+	editor.AddHistory("view table")
+	editor.AddHistory("view table")
+
+	if len(editor.History()) != 2 {
+		t.Errorf("Repeated lines should only be added to the history once. History is %q", editor.History())
+	}
+
+	expected := []string{"roll pool strength", "", "roll pool strength"}
+	for _, e := range expected {
+		line, err := editor.ReadLine(":> ")
+		if err != nil {
+			t.Fatalf("ReadLine returned an error: %v", err)
+		}
+		if line != e {
+			t.Errorf("ReadLine returned %q, expected %q", line, e)
+		}
+	}
+
+	// A new editor should load the history saved by the first one
+	loaded := newEditor("")
+	loaded.HistoryFile = history_file
+	loaded.HistorySize = 1
+	err := loaded.LoadHistory()
+	if err != nil {
+		t.Fatalf("LoadHistory returned an error: %v", err)
+	}
+	if history := loaded.History(); len(history) != 1 || history[0] != "view table" {
+		t.Errorf("LoadHistory should have kept the last line of the history, instead got %q", history)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	// Tab should fill in a single completion and the common part of several
+	complete := func(line string) (int, []string) {
+		start := strings.LastIndex(line, " ") + 1
+		var matches []string
+		for _, word := range []string{"strength", "stamina", "agility"} {
+			if strings.HasPrefix(word, line[start:]) {
+				matches = append(matches, word)
+			}
+		}
+		return start, matches
+	}
+
+	cases := map[string]string{
+		"roll pool ag\t\r": "roll pool agility ",
+		"roll pool s\t\r":  "roll pool st",
+		"roll pool x\t\r":  "roll pool x",
+	}
+	for input, expected := range cases {
+		editor := newEditor(input)
+		editor.Complete = complete
+		line, _ := editor.ReadLine(":> ")
+		if line != expected {
+			t.Errorf("ReadLine(%q) returned %q, expected %q", input, line, expected)
+		}
+	}
+}
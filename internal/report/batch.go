@@ -0,0 +1,58 @@
+package report
+
+import "fmt"
+
+// A Batch is the same pools rolled a number of times. Seed rolls the whole batch again and the seed
+// of each roll rolls just that one again
+type Batch struct {
+	Version int       `json:"version"`
+	Seed    int64     `json:"seed"`
+	Table   string    `json:"table,omitempty"`
+	Rolls   []Roll    `json:"rolls"`
+	Summary []Summary `json:"summary,omitempty"`
+}
+
+// A Summary is the totals of one pool across every roll of a batch
+type Summary struct {
+	Name       string  `json:"name"`
+	Expression string  `json:"expression"`
+	Rolls      int     `json:"rolls"`
+	Sum        int     `json:"sum"`
+	Min        int     `json:"min"`
+	Max        int     `json:"max"`
+	Mean       float64 `json:"mean"`
+}
+
+func NewBatch(seed int64, table string) Batch {
+	return Batch{Version: Version, Seed: seed, Table: table, Rolls: []Roll{}}
+}
+
+func Summarize(rolls []Roll) []Summary {
+	// Add up the totals of each pool across the rolls. Pools are matched by their position so pools
+	// with the same name are kept apart
+	var summary []Summary
+	for _, roll := range rolls {
+		for n, pool := range roll.Pools {
+			if n == len(summary) {
+				summary = append(summary, Summary{Name: pool.Name, Expression: pool.Expression, Min: pool.Total, Max: pool.Total})
+			}
+			total := &summary[n]
+			total.Rolls++
+			total.Sum += pool.Total
+			if pool.Total < total.Min {
+				total.Min = pool.Total
+			}
+			if pool.Total > total.Max {
+				total.Max = pool.Total
+			}
+		}
+	}
+	for n := range summary {
+		summary[n].Mean = float64(summary[n].Sum) / float64(summary[n].Rolls)
+	}
+	return summary
+}
+
+func (summary Summary) String() string {
+	return fmt.Sprintf("%s (%s): %d rolls, sum %d, min %d, max %d, mean %.2f", summary.Name, summary.Expression, summary.Rolls, summary.Sum, summary.Min, summary.Max, summary.Mean)
+}
@@ -0,0 +1,235 @@
+package report
+
+import (
+	"dicetable/pkg/dice"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of the JSON schema. It only goes up when a field is removed or changes meaning,
+// new fields can be added without changing it
+const Version = 1
+
+// The formats rolls can be written in. Text is the sentences dicetable has always printed and is left to the caller
+const (
+	Text     = "text"
+	JSON     = "json"
+	CSV      = "csv"
+	Markdown = "markdown"
+	TSV      = "tsv"
+)
+
+var Formats = []string{Text, JSON, CSV, Markdown, TSV}
+
+func Check(format string) error {
+	for _, f := range Formats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not an output format. Use %s", format, strings.Join(Formats, ", "))
+}
+
+// A Roll is everything rolled at once, with the seed that will roll the same faces again
+type Roll struct {
+	Version int    `json:"version"`
+	Seed    int64  `json:"seed"`
+	Table   string `json:"table,omitempty"`
+	Pools   []Pool `json:"pools"`
+	Total   int    `json:"total"`
+}
+
+// A Pool is one pool or expression that was rolled. Modifier is the constants of an expression added together
+type Pool struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	Dice       []Die  `json:"dice"`
+	Modifier   int    `json:"modifier"`
+	Total      int    `json:"total"`
+}
+
+// A Die is one die that was rolled. Kept is false if the die was dropped by kh, kl, dh or dl,
+// and Negative is set if the die is subtracted from the total
+type Die struct {
+	Sides    int  `json:"sides"`
+	Face     int  `json:"face"`
+	Kept     bool `json:"kept"`
+	Negative bool `json:"negative,omitempty"`
+}
+
+func NewRoll(seed int64, table string) Roll {
+	return Roll{Version: Version, Seed: seed, Table: table, Pools: []Pool{}}
+}
+
+func (roll *Roll) AddPool(name string, pool *dice.Pool) {
+	// Add a pool from a table. Every die of a pool is kept
+	added := Pool{Name: name, Expression: fmt.Sprintf("%dd%d", len(pool.Dice), pool.Sides), Dice: []Die{}, Total: pool.Total()}
+	for _, face := range pool.List() {
+		added.Dice = append(added.Dice, Die{Sides: pool.Sides, Face: face, Kept: true})
+	}
+	roll.Pools = append(roll.Pools, added)
+	roll.Total += added.Total
+}
+
+func (roll *Roll) AddExpression(name string, result dice.ExpressionResult) {
+	// Add a rolled expression. The dice of every term go in one pool
+	added := Pool{Name: name, Expression: result.Expression.Text, Dice: []Die{}, Total: result.Total}
+	for _, term := range result.Terms {
+		if term.Term.Count == 0 {
+			added.Modifier += term.Total
+			continue
+		}
+		for n, face := range term.Faces {
+			added.Dice = append(added.Dice, Die{Sides: term.Term.Sides, Face: face, Kept: term.Kept[n], Negative: term.Term.Negative})
+		}
+	}
+	roll.Pools = append(roll.Pools, added)
+	roll.Total += added.Total
+}
+
+func Write(w io.Writer, format string, roll Roll) error {
+	// Write the roll in one of the machine readable formats
+	switch format {
+	case JSON:
+		return writeJSON(w, roll)
+	case CSV:
+		return writeRows(w, ',', []Roll{roll}, false, nil)
+	case TSV:
+		return writeRows(w, '\t', []Roll{roll}, false, nil)
+	case Markdown:
+		return writeMarkdown(w, []Roll{roll}, false, nil, roll.Seed)
+	case Text:
+		return fmt.Errorf("text output is written by the command that rolled the dice")
+	}
+	return Check(format)
+}
+
+func WriteBatch(w io.Writer, format string, batch Batch) error {
+	// Write a batch in one of the machine readable formats. csv, tsv and markdown number each roll.
+	// markdown puts the summary in a second table after a blank line, csv and tsv in rows of type summary
+	switch format {
+	case JSON:
+		return writeJSON(w, batch)
+	case CSV:
+		return writeRows(w, ',', batch.Rolls, true, batch.Summary)
+	case TSV:
+		return writeRows(w, '\t', batch.Rolls, true, batch.Summary)
+	case Markdown:
+		return writeMarkdown(w, batch.Rolls, true, batch.Summary, batch.Seed)
+	case Text:
+		return fmt.Errorf("text output is written by the command that rolled the dice")
+	}
+	return Check(format)
+}
+
+func writeJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// The columns of csv and tsv output. There is one row for each die, or one row for a pool without dice.
+// Batches start each row with the number of the roll. With a summary every row starts with its type,
+// roll or summary, and the summary columns follow the others so the whole output is one table
+var columns = []string{"pool", "expression", "die", "sides", "face", "kept", "modifier", "total", "seed"}
+var summaryColumns = []string{"rolls", "sum", "min", "max", "mean"}
+
+func writeRows(w io.Writer, comma rune, rolls []Roll, numbered bool, summary []Summary) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+	header := columns
+	if numbered {
+		header = append([]string{"roll"}, header...)
+	}
+	if len(summary) > 0 {
+		header = append(append([]string{"type"}, header...), summaryColumns...)
+	}
+	writer.Write(header)
+	for number, roll := range rolls {
+		for _, pool := range roll.Pools {
+			common := func(row ...string) []string {
+				row = append([]string{pool.Name, pool.Expression}, row...)
+				row = append(row, strconv.Itoa(pool.Modifier), strconv.Itoa(pool.Total), strconv.FormatInt(roll.Seed, 10))
+				if numbered {
+					row = append([]string{strconv.Itoa(number + 1)}, row...)
+				}
+				if len(summary) > 0 {
+					row = append(append([]string{"roll"}, row...), make([]string, len(summaryColumns))...)
+				}
+				return row
+			}
+			if len(pool.Dice) == 0 {
+				writer.Write(common("", "", "", ""))
+			}
+			for n, die := range pool.Dice {
+				face := die.Face
+				if die.Negative {
+					face = -face
+				}
+				writer.Write(common(strconv.Itoa(n), strconv.Itoa(die.Sides), strconv.Itoa(face), strconv.FormatBool(die.Kept)))
+			}
+		}
+	}
+
+	for _, pool := range summary {
+		// A summary row leaves the columns of a die empty
+		row := make([]string, len(header))
+		row[0] = "summary"
+		first := len(header) - len(columns) - len(summaryColumns)
+		row[first], row[first+1] = pool.Name, pool.Expression
+		copy(row[len(header)-len(summaryColumns):], []string{strconv.Itoa(pool.Rolls), strconv.Itoa(pool.Sum),
+			strconv.Itoa(pool.Min), strconv.Itoa(pool.Max), strconv.FormatFloat(pool.Mean, 'f', 2, 64)})
+		writer.Write(row)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeMarkdown(w io.Writer, rolls []Roll, numbered bool, summary []Summary, seed int64) error {
+	// A table with a row for each pool. Dropped dice are struck through
+	var out strings.Builder
+	if numbered {
+		out.WriteString("| Roll | Pool | Dice | Faces | Total |\n|---:|---|---|---|---:|\n")
+	} else {
+		out.WriteString("| Pool | Dice | Faces | Total |\n|---|---|---|---:|\n")
+	}
+	for number, roll := range rolls {
+		for _, pool := range roll.Pools {
+			faces := make([]string, len(pool.Dice))
+			for n, die := range pool.Dice {
+				faces[n] = strconv.Itoa(die.Face)
+				if die.Negative {
+					faces[n] = "-" + faces[n]
+				}
+				if !die.Kept {
+					faces[n] = "~~" + faces[n] + "~~"
+				}
+			}
+			if pool.Modifier != 0 {
+				faces = append(faces, fmt.Sprintf("%+d", pool.Modifier))
+			}
+			if numbered {
+				fmt.Fprintf(&out, "| %d ", number+1)
+			}
+			fmt.Fprintf(&out, "| %s | %s | %s | %d |\n", escapeCell(pool.Name), pool.Expression, strings.Join(faces, ", "), pool.Total)
+		}
+	}
+
+	if len(summary) > 0 {
+		out.WriteString("\n| Pool | Dice | Rolls | Sum | Min | Max | Mean |\n|---|---|---:|---:|---:|---:|---:|\n")
+		for _, pool := range summary {
+			fmt.Fprintf(&out, "| %s | %s | %d | %d | %d | %d | %.2f |\n", escapeCell(pool.Name), pool.Expression, pool.Rolls, pool.Sum, pool.Min, pool.Max, pool.Mean)
+		}
+	}
+	fmt.Fprintf(&out, "\nSeed: %d\n", seed)
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+func escapeCell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
@@ -0,0 +1,140 @@
+package report_test
+
+import (
+	"dicetable/internal/report"
+	"dicetable/pkg/dice"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func rolled() report.Roll {
+	// A pool and an expression with a dropped die, a subtracted die and a modifier
+	roll := report.NewRoll(42, "camp")
+	pool := dice.CreatePool(2, 6)
+	pool.SetFaces([]int{3, 5}, false)
+	roll.AddPool("strength", pool)
+
+	expression, _ := dice.ParseExpression("2d20kh1-1d4+3")
+	result := dice.ExpressionResult{Expression: expression, Total: 16, Terms: []dice.TermResult{
+		{Term: expression.Terms[0], Faces: []int{15, 7}, Kept: []bool{true, false}, Total: 15},
+		{Term: expression.Terms[1], Faces: []int{2}, Kept: []bool{true}, Total: -2},
+		{Term: expression.Terms[2], Total: 3},
+	}}
+	roll.AddExpression("attack", result)
+	return roll
+}
+
+func TestJSON(t *testing.T) {
+	// The JSON should keep the names, faces, kept flags, totals and seed
+	var out strings.Builder
+	if err := report.Write(&out, report.JSON, rolled()); err != nil {
+		t.Fatal(err)
+	}
+	var decoded report.Roll
+	if err := json.Unmarshal([]byte(out.String()), &decoded); err != nil {
+		t.Fatalf("The output should be JSON: %v\n%s", err, out.String())
+	}
+	if decoded.Version != report.Version || decoded.Seed != 42 || decoded.Table != "camp" || decoded.Total != 24 {
+		t.Errorf("The roll should have the version, seed 42, table camp and total 24, instead got %+v", decoded)
+	}
+	attack := decoded.Pools[1]
+	if attack.Name != "attack" || attack.Modifier != 3 || attack.Total != 16 || len(attack.Dice) != 3 {
+		t.Fatalf("attack should have 3 dice, modifier 3 and total 16, instead got %+v", attack)
+	}
+	if attack.Dice[1].Kept || !attack.Dice[0].Kept || !attack.Dice[2].Negative {
+		t.Errorf("The second d20 should be dropped and the d4 negative, instead got %+v", attack.Dice)
+	}
+	if !strings.Contains(out.String(), `"kept": false`) {
+		t.Errorf("Dropped dice should say kept false:\n%s", out.String())
+	}
+}
+
+func TestRows(t *testing.T) {
+	// csv and tsv should have a header and a row for each die
+	var out strings.Builder
+	report.Write(&out, report.CSV, rolled())
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 6 || lines[0] != "pool,expression,die,sides,face,kept,modifier,total,seed" {
+		t.Fatalf("There should be a header and 5 rows:\n%s", out.String())
+	}
+	if lines[4] != "attack,2d20kh1-1d4+3,1,20,7,false,3,16,42" || lines[5] != "attack,2d20kh1-1d4+3,2,4,-2,true,3,16,42" {
+		t.Errorf("The rows of attack should mark the dropped and subtracted dice:\n%s", out.String())
+	}
+
+	out.Reset()
+	report.Write(&out, report.TSV, rolled())
+	if !strings.HasPrefix(out.String(), "pool\texpression\tdie\t") {
+		t.Errorf("tsv should be separated by tabs:\n%s", out.String())
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	var out strings.Builder
+	report.Write(&out, report.Markdown, rolled())
+	if !strings.Contains(out.String(), "| attack | 2d20kh1-1d4+3 | 15, ~~7~~, -2, +3 | 16 |") {
+		t.Errorf("Dropped dice should be struck through and the modifier shown:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Seed: 42") {
+		t.Errorf("The seed should be shown:\n%s", out.String())
+	}
+}
+
+func TestCheck(t *testing.T) {
+	for _, format := range report.Formats {
+		if err := report.Check(format); err != nil {
+			t.Errorf("%s should be a format: %v", format, err)
+		}
+	}
+	if err := report.Check("xml"); err == nil {
+		t.Errorf("xml should not be a format")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	// The summary should add up each pool across the rolls
+	var rolls []report.Roll
+	for _, faces := range [][]int{{1, 2}, {6, 6}, {3, 4}} {
+		roll := report.NewRoll(1, "")
+		pool := dice.CreatePool(2, 6)
+		pool.SetFaces(faces, false)
+		roll.AddPool("pair", pool)
+		rolls = append(rolls, roll)
+	}
+	summary := report.Summarize(rolls)
+	if len(summary) != 1 {
+		t.Fatalf("There should be one pool in the summary, instead got %+v", summary)
+	}
+	if pair := summary[0]; pair.Rolls != 3 || pair.Sum != 22 || pair.Min != 3 || pair.Max != 12 || pair.Mean < 7.33 || pair.Mean > 7.34 {
+		t.Errorf("pair should have 3 rolls, sum 22, min 3, max 12 and mean 7.33, instead got %+v", pair)
+	}
+
+	batch := report.NewBatch(9, "")
+	batch.Rolls = rolls
+	batch.Summary = summary
+	var out strings.Builder
+	report.WriteBatch(&out, report.CSV, batch)
+	// The summary goes in the same table, so every row has the columns of the header
+	rows, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("The batch should be valid csv: %v\n%s", err, out.String())
+	}
+	if len(rows) != 8 || strings.Join(rows[0], ",") != "type,roll,pool,expression,die,sides,face,kept,modifier,total,seed,rolls,sum,min,max,mean" {
+		t.Fatalf("The header should have a type column first and the summary columns last:\n%s", out.String())
+	}
+	if strings.Join(rows[6], ",") != "roll,3,pair,2d6,1,6,4,true,0,7,1,,,,," {
+		t.Errorf("Each roll row should start with its type and the number of its roll:\n%s", out.String())
+	}
+	if strings.Join(rows[7], ",") != "summary,,pair,2d6,,,,,,,,3,22,3,12,7.33" {
+		t.Errorf("The summary row should follow the rolls:\n%s", out.String())
+	}
+
+	// Without a summary there is no type column
+	out.Reset()
+	batch.Summary = nil
+	report.WriteBatch(&out, report.TSV, batch)
+	if !strings.HasPrefix(out.String(), "roll\tpool\t") {
+		t.Errorf("A batch without a summary should start with the roll column:\n%s", out.String())
+	}
+}
@@ -0,0 +1,90 @@
+package tablecommands
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+func alias(s *session, args []string) (string, error) {
+	// List the aliases. alias
+	// Show one alias. alias [name]
+	// Make a name stand for a command. alias [name] [command...]
+	switch len(args) {
+	case 0:
+		if len(s.aliases) == 0 {
+			return "There are no aliases.\n", nil
+		}
+		names := make([]string, 0, len(s.aliases))
+		for name := range s.aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return_str := "Aliases:\n"
+		for _, name := range names {
+			return_str += fmt.Sprintf("%s = %s\n", name, s.aliases[name])
+		}
+		return return_str, nil
+	case 1:
+		command, ok := s.aliases[args[0]]
+		if !ok {
+			return "", argErrorf(args[0], "%s is not an alias.", args[0])
+		}
+		return fmt.Sprintf("%s = %s\n", args[0], command), nil
+	}
+
+	quoted := make([]string, len(args)-1)
+	for n, arg := range args[1:] {
+		quoted[n] = quoteArg(arg)
+	}
+	err := s.setAlias(args[0], strings.Join(quoted, " "))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s now runs %s\n", args[0], s.aliases[args[0]]), nil
+}
+
+func unalias(s *session, args []string) (string, error) {
+	// Remove aliases. unalias [names...]
+	if len(args) < 1 {
+		return "", errors.New("Not enough arguments provided. unalias [names...]")
+	}
+	return_str := ""
+	var errs []error
+	for _, name := range args {
+		if _, ok := s.aliases[name]; !ok {
+			errs = append(errs, argErrorf(name, "%s is not an alias.", name))
+			continue
+		}
+		delete(s.aliases, name)
+		return_str += fmt.Sprintf("Removed alias %s\n", name)
+	}
+	return return_str, joinErrors(errs)
+}
+
+func (s *session) setAlias(name string, command string) error {
+	// Aliases can't hide a command, and the command they stand for has to be one
+	if _, ok := commands[name]; ok || name == "exit" {
+		return argErrorf(name, "%s is already a command.", name)
+	}
+	if name == "" || strings.ContainsAny(name, " \t\n\"'\\") {
+		return argErrorf(name, "%s can't be the name of an alias.", quoteArg(name))
+	}
+	tokens, err := tokenize(command)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("The alias %s needs a command to run.", name)
+	}
+	words := make([]string, len(tokens))
+	for n, t := range tokens {
+		words[n] = t.Text
+	}
+	if _, ok := commands[words[0]]; !ok && words[0] != "exit" && !isExpression(words) {
+		return argErrorf(words[0], "%s is not a command, aliases have to start with one or be a dice expression.", words[0])
+	}
+	s.aliases[name] = command
+	return nil
+}
@@ -0,0 +1,232 @@
+package tablecommands
+
+import (
+	"dicetable/pkg/dice"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A change made to one pool by a command
+type poolChange struct {
+	Name   string
+	Before *dice.Pool // nil if the pool was added
+	After  *dice.Pool // nil if the pool was removed
+}
+
+func diffTables(before *dice.Table, after *dice.Table) []poolChange {
+	// Compare two states of a table and return the pools that are different, sorted by name
+	var changes []poolChange
+	for name, pool := range before.Pools {
+		if other, ok := after.Pools[name]; !ok {
+			changes = append(changes, poolChange{Name: name, Before: pool})
+		} else if !samePool(pool, other) {
+			changes = append(changes, poolChange{Name: name, Before: pool, After: other})
+		}
+	}
+	for name, pool := range after.Pools {
+		if _, ok := before.Pools[name]; !ok {
+			changes = append(changes, poolChange{Name: name, After: pool})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// A change made to one counter by a command
+type counterChange struct {
+	Name   string
+	Before *dice.Counter // nil if the counter was added
+	After  *dice.Counter // nil if the counter was removed
+}
+
+func diffCounters(before *dice.Table, after *dice.Table) []counterChange {
+	// Compare the counters of two states of a table and return the ones that are different, sorted by name
+	var changes []counterChange
+	for name, counter := range before.Counters {
+		if other, ok := after.Counters[name]; !ok {
+			changes = append(changes, counterChange{Name: name, Before: counter})
+		} else if !counter.Equal(other) {
+			changes = append(changes, counterChange{Name: name, Before: counter, After: other})
+		}
+	}
+	for name, counter := range after.Counters {
+		if _, ok := before.Counters[name]; !ok {
+			changes = append(changes, counterChange{Name: name, After: counter})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// A change to the initiative order of a table. Before is nil if it was started and After is nil if it was ended
+type initiativeChange struct {
+	Before *dice.Initiative
+	After  *dice.Initiative
+}
+
+func diffInitiative(before *dice.Table, after *dice.Table) *initiativeChange {
+	// Compare the initiative order of two states of a table, returning nil if it is the same
+	if before.Initiative == nil && after.Initiative == nil {
+		return nil
+	}
+	if before.Initiative != nil && after.Initiative != nil && before.Initiative.Equal(after.Initiative) {
+		return nil
+	}
+	return &initiativeChange{Before: before.Initiative, After: after.Initiative}
+}
+
+// The changes a command made to one table. Created and Deleted are set if the command added or removed the table
+type tableChange struct {
+	Table      string
+	Pools      []poolChange
+	Counters   []counterChange
+	Initiative *initiativeChange
+	Created    bool
+	Deleted    bool
+}
+
+func diffAll(before map[string]*dice.Table, after map[string]*dice.Table) []tableChange {
+	// Compare two states of the tables in a session and return the tables that are different, sorted by name
+	empty := &dice.Table{Pools: make(map[string]*dice.Pool)}
+	var changes []tableChange
+	for name, table := range before {
+		if other, ok := after[name]; !ok {
+			changes = append(changes, tableChange{Table: name, Pools: diffTables(table, empty), Counters: diffCounters(table, empty),
+				Initiative: diffInitiative(table, empty), Deleted: true})
+		} else {
+			pools, counters, initiative := diffTables(table, other), diffCounters(table, other), diffInitiative(table, other)
+			if len(pools) > 0 || len(counters) > 0 || initiative != nil {
+				changes = append(changes, tableChange{Table: name, Pools: pools, Counters: counters, Initiative: initiative})
+			}
+		}
+	}
+	for name, table := range after {
+		if _, ok := before[name]; !ok {
+			changes = append(changes, tableChange{Table: name, Pools: diffTables(empty, table), Counters: diffCounters(empty, table),
+				Initiative: diffInitiative(empty, table), Created: true})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Table < changes[j].Table })
+	return changes
+}
+
+func samePool(a *dice.Pool, b *dice.Pool) bool {
+	if a.Sides != b.Sides || a.Description != b.Description || len(a.Dice) != len(b.Dice) || !sameTags(a, b) {
+		return false
+	}
+	for n := range a.Dice {
+		if *a.Dice[n] != *b.Dice[n] {
+			return false
+		}
+	}
+	return sameHistory(a, b)
+}
+
+func sameTags(a *dice.Pool, b *dice.Pool) bool {
+	if len(a.Tags) != len(b.Tags) {
+		return false
+	}
+	for n := range a.Tags {
+		if a.Tags[n] != b.Tags[n] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameHistory(a *dice.Pool, b *dice.Pool) bool {
+	if len(a.History) != len(b.History) {
+		return false
+	}
+	for n := range a.History {
+		if len(a.History[n]) != len(b.History[n]) {
+			return false
+		}
+		for i := range a.History[n] {
+			if a.History[n][i] != b.History[n][i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (change poolChange) String() string {
+	// Describe the change in one line
+	switch {
+	case change.Before == nil:
+		return fmt.Sprintf("+ pool %s: %dd%d %d", change.Name, len(change.After.Dice), change.After.Sides, change.After.List())
+	case change.After == nil:
+		return fmt.Sprintf("- pool %s: %dd%d %d", change.Name, len(change.Before.Dice), change.Before.Sides, change.Before.List())
+	default:
+		line := fmt.Sprintf("~ pool %s: %dd%d %d -> %dd%d %d", change.Name,
+			len(change.Before.Dice), change.Before.Sides, change.Before.List(),
+			len(change.After.Dice), change.After.Sides, change.After.List())
+		// Show changes to the description and tags since the dice can look the same
+		if change.Before.Description != change.After.Description {
+			line += fmt.Sprintf(", description %q -> %q", change.Before.Description, change.After.Description)
+		}
+		if !sameTags(change.Before, change.After) {
+			line += fmt.Sprintf(", tags %s -> %s", change.Before.Tags, change.After.Tags)
+		}
+		return line
+	}
+}
+
+func (change counterChange) String() string {
+	switch {
+	case change.Before == nil:
+		return fmt.Sprintf("+ counter %s: %s", change.Name, change.After)
+	case change.After == nil:
+		return fmt.Sprintf("- counter %s: %s", change.Name, change.Before)
+	default:
+		return fmt.Sprintf("~ counter %s: %s -> %s", change.Name, change.Before, change.After)
+	}
+}
+
+func (change initiativeChange) String() string {
+	describe := func(order *dice.Initiative) string {
+		if current := order.Current(); current != nil {
+			return fmt.Sprintf("round %d, %s's turn", order.Round, current.Name)
+		}
+		return fmt.Sprintf("round %d, no combatants", order.Round)
+	}
+	switch {
+	case change.Before == nil:
+		return fmt.Sprintf("+ initiative: %d combatants, %s", len(change.After.Combatants), describe(change.After))
+	case change.After == nil:
+		return fmt.Sprintf("- initiative: %d combatants, %s", len(change.Before.Combatants), describe(change.Before))
+	default:
+		return fmt.Sprintf("~ initiative: %s -> %s", describe(change.Before), describe(change.After))
+	}
+}
+
+func describeChanges(changes []tableChange, active string) string {
+	// Describe the changes one line per pool. Changes to tables other than the active one are put under the table's name
+	var lines []string
+	for _, change := range changes {
+		indent := ""
+		if change.Table != active || change.Created || change.Deleted {
+			indent = "  "
+			switch {
+			case change.Created:
+				lines = append(lines, fmt.Sprintf("+ table %s", quoteArg(change.Table)))
+			case change.Deleted:
+				lines = append(lines, fmt.Sprintf("- table %s", quoteArg(change.Table)))
+			default:
+				lines = append(lines, fmt.Sprintf("~ table %s", quoteArg(change.Table)))
+			}
+		}
+		for _, pool := range change.Pools {
+			lines = append(lines, indent+pool.String())
+		}
+		for _, counter := range change.Counters {
+			lines = append(lines, indent+counter.String())
+		}
+		if change.Initiative != nil {
+			lines = append(lines, indent+change.Initiative.String())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,173 @@
+package tablecommands
+
+import (
+	"dicetable/pkg/dice"
+	"sort"
+	"strings"
+)
+
+// The keywords that can follow each command
+var subcommands = map[string][]string{
+	"roll":     {"pool", "table"},
+	"add":      {"die", "pool"},
+	"subtract": {"die", "pool"},
+	"view":     {"pool", "table"},
+	"clear":    {"pool", "table"},
+	"set":      {"die", "pool", "table"},
+	"table":    {"new", "use", "list", "copy", "delete", "save", "load", "saved"},
+	"copy":     {"pool"},
+	"move":     {"pool"},
+	"history":  {"pool"},
+	"stats":    {"pool"},
+	"rename":   {"pool"},
+	"describe": {"pool"},
+	"tag":      {"pool", "list"},
+	"untag":    {"pool"},
+	"reroll":   {"pool"},
+	"sort":     {"pool"},
+	"counter":  {"new", "clock", "inc", "dec", "set", "delete", "list"},
+	"init":     {"roll", "add", "remove", "next", "prev", "delay", "end", "view"},
+}
+
+func Complete(table dice.Table, line string) (int, []string) {
+	// Return the position of the word being typed at the end of line and the words that could complete it.
+	// The first word completes to a command, the second to the keywords of that command,
+	// and later words to the names of the pools or tables when the command takes them.
+	return newSession(&table).complete(line)
+}
+
+func (s *session) complete(line string) (int, []string) {
+	tokens, err := tokenize(line)
+	if err != nil {
+		// An unterminated quote is part of the word being completed, close it and try again
+		if syntax_err, ok := err.(*SyntaxError); ok && strings.HasPrefix(syntax_err.Msg, "unterminated") {
+			tokens, err = tokenize(line + string([]rune(line)[syntax_err.Pos]))
+		}
+		if err != nil {
+			return 0, nil
+		}
+	}
+
+	// If the last token runs to the end of the line it is the word being completed, otherwise a new word is started
+	length := len([]rune(line))
+	partial := token{Pos: length, End: length}
+	if len(tokens) > 0 && tokens[len(tokens)-1].End >= length {
+		partial = tokens[len(tokens)-1]
+		tokens = tokens[:len(tokens)-1]
+	}
+	args := make([]string, len(tokens))
+	for n, t := range tokens {
+		args[n] = t.Text
+	}
+
+	var options []string
+	switch {
+	case len(args) == 0:
+		options = append(options, "exit")
+		for name := range commands {
+			options = append(options, name)
+		}
+		for name := range s.aliases {
+			options = append(options, name)
+		}
+	case len(args) == 1 && args[0] == "config":
+		options = append(options, "save", "file")
+		for name := range settings {
+			options = append(options, name)
+		}
+	case args[0] == "unalias" || (len(args) == 1 && args[0] == "alias"):
+		for name := range s.aliases {
+			options = append(options, name)
+		}
+	case len(args) == 1 && args[0] == "roll":
+		options = append(append(options, subcommands["roll"]...), s.tagSelectors()...)
+	case takesCombatantNames(args):
+		options = s.combatantNames()
+	case len(args) == 1:
+		options = subcommands[args[0]]
+	case takesPoolNames(args):
+		for name := range s.table.Pools {
+			options = append(options, name)
+		}
+		options = append(options, s.tagSelectors()...)
+	case takesTags(args):
+		options = s.tagNames()
+	case takesTableNames(args):
+		options = s.tableNames()
+	case len(args) == 2 && args[0] == "counter" && args[1] != "new" && args[1] != "clock":
+		options = s.counterNames()
+	}
+
+	var matches []string
+	for _, option := range options {
+		if strings.HasPrefix(option, partial.Text) {
+			matches = append(matches, quoteArg(option))
+		}
+	}
+	sort.Strings(matches)
+	return partial.Pos, matches
+}
+
+func takesPoolNames(args []string) bool {
+	// Check if the next argument of a command is the name of a pool
+	switch args[0] + " " + args[1] {
+	case "roll pool", "view pool", "clear pool", "subtract pool", "add die", "subtract die", "stats pool", "sort pool":
+		return true
+	case "set pool", "set die", "copy pool", "move pool", "history pool", "rename pool", "describe pool", "tag pool", "untag pool", "reroll pool":
+		return len(args) == 2
+	}
+	return false
+}
+
+func takesCombatantNames(args []string) bool {
+	// Check if the next argument of a command is the name of a combatant in the initiative order
+	if len(args) == 1 {
+		return args[0] == "delay"
+	}
+	switch args[0] + " " + args[1] {
+	case "init remove":
+		return true
+	case "init delay":
+		return len(args) == 2
+	}
+	return false
+}
+
+func takesTags(args []string) bool {
+	// Check if the next argument of a command is a tag
+	switch args[0] + " " + args[1] {
+	case "tag pool", "untag pool":
+		return len(args) > 2
+	}
+	return false
+}
+
+func (s *session) tagSelectors() []string {
+	// tag:[tag] for each tag on the table, for completing lists of pool names
+	var selectors []string
+	for _, t := range s.tagNames() {
+		selectors = append(selectors, "tag:"+t)
+	}
+	return selectors
+}
+
+func takesTableNames(args []string) bool {
+	// Check if the next argument of a command is the name of a table
+	switch args[0] + " " + args[1] {
+	case "table use", "table copy", "table delete", "table save":
+		return len(args) == 2
+	case "copy pool", "move pool":
+		return len(args) == 3
+	}
+	return false
+}
+
+func quoteArg(arg string) string {
+	// Quote an argument so it will be read back as a single token
+	if arg != "" && !strings.ContainsAny(arg, " \t\n\"'\\") {
+		return arg
+	}
+	arg = strings.ReplaceAll(arg, `\`, `\\`)
+	arg = strings.ReplaceAll(arg, `"`, `\"`)
+	return `"` + arg + `"`
+}
@@ -0,0 +1,238 @@
+package tablecommands
+
+import (
+	"dicetable/internal/config"
+	"dicetable/internal/report"
+	"dicetable/pkg/dice"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The prompt used when the prompt setting isn't changed
+const defaultPrompt = "{table}:> "
+
+// The config file new sessions take their settings and aliases from, and config save writes to. nil until SetConfig is called
+var config_file *config.File
+
+func SetConfig(file *config.File) error {
+	// Use the settings and aliases of a config file in new sessions. Returns an error for the first setting
+	// or alias in the file that can't be used. Keys that aren't settings of the prompt are left for the caller. nil stops using a config file
+	s := newSession(&dice.Table{Pools: make(map[string]*dice.Pool)})
+	if err := s.applyConfig(file); err != nil {
+		return fmt.Errorf("%s: %v", file.Path, err)
+	}
+	config_file = file
+	return nil
+}
+
+func (s *session) applyConfig(file *config.File) error {
+	if file == nil {
+		return nil
+	}
+	for _, key := range file.Keys() {
+		value, _ := file.Get(key)
+		if option, ok := settings[key]; ok {
+			if err := option.set(s, value); err != nil {
+				return fmt.Errorf("%s: %v", key, err)
+			}
+		} else if strings.HasPrefix(key, "alias.") {
+			if err := s.setAlias(strings.TrimPrefix(key, "alias."), value); err != nil {
+				return fmt.Errorf("%s: %v", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// A setting of the session that can be viewed and changed with the config command
+type setting struct {
+	help string
+	get  func(s *session) string
+	set  func(s *session, value string) error
+}
+
+var settings = map[string]setting{
+	"display": {
+		help: "how roll and view show the faces of the dice (list/sorted/grouped/auto)",
+		get:  func(s *session) string { return s.display },
+		set: func(s *session, value string) error {
+			switch value {
+			case dice.ShowList, dice.ShowSorted, dice.ShowGrouped, dice.ShowAuto:
+				s.display = value
+				return nil
+			}
+			return argErrorf(value, "%s is not a way to show dice. Use list, sorted, grouped or auto.", quoteArg(value))
+		},
+	},
+	"format": {
+		help: "how roll and r print what they rolled (text/json/csv/markdown/tsv)",
+		get:  func(s *session) string { return s.format },
+		set: func(s *session, value string) error {
+			if err := report.Check(value); err != nil {
+				return argErrorf(value, "%s is not an output format. Use text, json, csv, markdown or tsv.", quoteArg(value))
+			}
+			s.format = value
+			return nil
+		},
+	},
+	"prompt": {
+		help: "the prompt shown before each command. {table}, {pools}, {round} and {turn} are filled in",
+		get:  func(s *session) string { return strconv.Quote(s.prompt) },
+		set: func(s *session, value string) error {
+			s.prompt = value
+			return nil
+		},
+	},
+	"confirm": {
+		help: "ask before running commands that remove pools or dice (on/off)",
+		get:  func(s *session) string { return formatBool(s.confirm_destructive) },
+		set: func(s *session, value string) error {
+			b, err := config.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			s.confirm_destructive = b
+			return nil
+		},
+	},
+}
+
+func configCommand(s *session, args []string) (string, error) {
+	// View all settings. config
+	// View one setting. config [name]
+	// Change a setting. config [name] [value]
+	// Keep the settings and aliases for next time. config save
+	// View or change the config file. config file {optional} [key] {optional} [value]
+	if len(args) > 0 && args[0] == "save" {
+		return saveConfig(s, args[1:])
+	}
+	if len(args) > 0 && args[0] == "file" {
+		return configFile(s, args[1:])
+	}
+	if len(args) == 0 {
+		names := make([]string, 0, len(settings))
+		for name := range settings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return_str := "Settings:\n"
+		for _, name := range names {
+			return_str += fmt.Sprintf("%s = %s - %s\n", name, settings[name].get(s), settings[name].help)
+		}
+		return return_str, nil
+	}
+
+	option, ok := settings[args[0]]
+	if !ok {
+		return "", argErrorf(args[0], "%s is not a setting. Use config to list the settings.", args[0])
+	}
+	switch len(args) {
+	case 1:
+		return fmt.Sprintf("%s = %s\n", args[0], option.get(s)), nil
+	case 2:
+		err := option.set(s, args[1])
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Set %s to %s\n", args[0], option.get(s)), nil
+	}
+	return "", errors.New("config command format is config {optional} [setting] {optional} [value]")
+}
+
+func saveConfig(s *session, args []string) (string, error) {
+	// Write the settings and aliases of the session to the config file so new sessions start with them
+	if len(args) != 0 {
+		return "", errors.New("config save format is config save")
+	}
+	if config_file == nil {
+		return "", errors.New("There is no config file to save to.")
+	}
+	if s.previewing {
+		return fmt.Sprintf("Would save the settings and %d aliases to %s\n", len(s.aliases), config_file.Path), nil
+	}
+	for name, option := range settings {
+		value := option.get(s)
+		if name == "prompt" {
+			value = s.prompt
+		}
+		config_file.Set(name, value)
+	}
+	for _, key := range config_file.Keys() {
+		if strings.HasPrefix(key, "alias.") {
+			config_file.Unset(key)
+		}
+	}
+	for name, alias := range s.aliases {
+		config_file.Set("alias."+name, alias)
+	}
+	if err := config_file.Save(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Saved the settings and %d aliases to %s\n", len(s.aliases), config_file.Path), nil
+}
+
+func configFile(s *session, args []string) (string, error) {
+	// Show the config file, or change a key in it. An empty value removes the key.
+	// Changes to the file take effect the next time dicetable starts
+	if config_file == nil {
+		return "", errors.New("There is no config file.")
+	}
+	switch len(args) {
+	case 0:
+		return_str := fmt.Sprintf("%s:\n", config_file.Path)
+		for _, key := range config_file.Keys() {
+			value, _ := config_file.Get(key)
+			if value != strings.TrimSpace(value) {
+				value = strconv.Quote(value)
+			}
+			return_str += fmt.Sprintf("%s = %s\n", key, value)
+		}
+		return return_str, nil
+	case 1:
+		value, ok := config_file.Get(args[0])
+		if !ok {
+			return "", argErrorf(args[0], "%s is not set in %s", args[0], config_file.Path)
+		}
+		return fmt.Sprintf("%s = %s\n", args[0], value), nil
+	case 2:
+		if s.previewing {
+			return fmt.Sprintf("Would set %s to %q in %s\n", args[0], args[1], config_file.Path), nil
+		}
+		if args[1] == "" {
+			config_file.Unset(args[0])
+		} else {
+			config_file.Set(args[0], args[1])
+		}
+		if err := config_file.Save(); err != nil {
+			return "", err
+		}
+		if args[1] == "" {
+			return fmt.Sprintf("Removed %s from %s\n", args[0], config_file.Path), nil
+		}
+		return fmt.Sprintf("Set %s to %s in %s\n", args[0], args[1], config_file.Path), nil
+	}
+	return "", errors.New("config file format is config file {optional} [key] {optional} [value]")
+}
+
+func (s *session) renderPrompt() string {
+	// Fill in the prompt setting with the table, how many pools it has, and the round and whose turn it is in a fight
+	round, turn := "", ""
+	if order := s.table.Initiative; order != nil {
+		round = strconv.Itoa(order.Round)
+		if current := order.Current(); current != nil {
+			turn = current.Name
+		}
+	}
+	return strings.NewReplacer("{table}", s.table.Name, "{pools}", strconv.Itoa(len(s.table.Pools)), "{round}", round, "{turn}", turn).Replace(s.prompt)
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
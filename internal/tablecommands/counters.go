@@ -0,0 +1,176 @@
+package tablecommands
+
+import (
+	"dicetable/pkg/dice"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func counterCommand(s *session, args []string) (string, error) {
+	// Keep numbers on the table next to the dice.
+	// counter new [name] [value] {optional} [--min number] [--max number], counter clock [name] [segments],
+	// counter inc/dec [name] {optional} [amount], counter set [name] [value], counter delete [name], counter list
+	// An amount or value can be pool:[pool name] to use the total of a pool
+	format := "counter command format is counter [new/clock/inc/dec/set/delete/list] [counter name] [value]"
+	if len(args) < 1 {
+		return "", errors.New(format)
+	}
+
+	switch args[0] {
+	case "list":
+		return listCounters(s.table), nil
+	case "new":
+		return newCounter(s, args[1:])
+	case "clock":
+		if len(args) != 3 {
+			return "", errors.New("counter clock format is counter clock [name] [segments]")
+		}
+		if _, ok := s.table.Counters[args[1]]; ok {
+			return "", argErrorf(args[1], "There is already a counter named %s.", args[1])
+		}
+		segments, err := strconv.Atoi(args[2])
+		if err != nil {
+			return "", argErrorf(args[2], "%s is not a number of segments.", quoteArg(args[2]))
+		}
+		clock, err := dice.NewClock(segments)
+		if err != nil {
+			return "", err
+		}
+		s.table.AddCounter(args[1], clock)
+		return fmt.Sprintf("Added clock %s: %s\n", args[1], clock), nil
+	case "inc", "dec", "set":
+		if len(args) < 2 || len(args) > 3 || (args[0] == "set" && len(args) != 3) {
+			return "", fmt.Errorf("counter %s format is counter %s [counter name] {optional} [amount or pool:[pool name]]", args[0], args[0])
+		}
+		counter, ok := s.table.Counters[args[1]]
+		if !ok {
+			return "", argErrorf(args[1], "%s is not the name of a counter on the table.", args[1])
+		}
+		amount, from := 1, ""
+		if len(args) == 3 {
+			var err error
+			amount, from, err = s.counterAmount(args[2])
+			if err != nil {
+				return "", err
+			}
+		}
+
+		before := counter.Value
+		wanted := amount
+		switch args[0] {
+		case "inc":
+			wanted = before + amount
+		case "dec":
+			wanted = before - amount
+		}
+		counter.Set(wanted)
+
+		return_str := fmt.Sprintf("Counter %s: %d -> %s%s\n", args[1], before, counter, from)
+		if counter.Value != wanted {
+			return_str += fmt.Sprintf("Stopped at %d instead of %d.\n", counter.Value, wanted)
+		}
+		if counter.Clock && counter.Full() && before != counter.Value {
+			return_str += fmt.Sprintf("Clock %s is full.\n", args[1])
+		}
+		return return_str, nil
+	case "delete":
+		if len(args) != 2 {
+			return "", errors.New("counter delete format is counter delete [counter name]")
+		}
+		if _, ok := s.table.Counters[args[1]]; !ok {
+			return "", argErrorf(args[1], "%s is not the name of a counter on the table.", args[1])
+		}
+		delete(s.table.Counters, args[1])
+		return fmt.Sprintf("Deleted counter %s.\n", args[1]), nil
+	}
+	return "", errors.New(format)
+}
+
+func newCounter(s *session, args []string) (string, error) {
+	format := "counter new format is counter new [name] [value] {optional} [--min number] [--max number]"
+	var rest []string
+	counter := &dice.Counter{}
+	for n := 0; n < len(args); n++ {
+		if args[n] != "--min" && args[n] != "--max" {
+			rest = append(rest, args[n])
+			continue
+		}
+		if n+1 >= len(args) {
+			return "", argErrorf(args[n], "%s needs a number after it.", args[n])
+		}
+		bound, err := strconv.Atoi(args[n+1])
+		if err != nil {
+			return "", argErrorf(args[n+1], "%s is not a number for %s.", quoteArg(args[n+1]), args[n])
+		}
+		if args[n] == "--min" {
+			counter.Min = &bound
+		} else {
+			counter.Max = &bound
+		}
+		n++
+	}
+	if len(rest) != 2 {
+		return "", errors.New(format)
+	}
+	name := rest[0]
+	if _, ok := s.table.Counters[name]; ok {
+		return "", argErrorf(name, "There is already a counter named %s.", name)
+	}
+	if counter.Min != nil && counter.Max != nil && *counter.Min > *counter.Max {
+		return "", errors.New("The min of a counter cannot be more than its max.")
+	}
+	value, from, err := s.counterAmount(rest[1])
+	if err != nil {
+		return "", err
+	}
+	counter.Set(value)
+	s.table.AddCounter(name, counter)
+	return fmt.Sprintf("Added counter %s: %s%s\n", name, counter, from), nil
+}
+
+func (s *session) counterAmount(arg string) (int, string, error) {
+	// Read a number, or the total of a pool from pool:[pool name].
+	// Also returns a note saying where the number came from
+	if strings.HasPrefix(arg, "pool:") {
+		name := strings.TrimPrefix(arg, "pool:")
+		pool, ok := s.table.Pools[name]
+		if !ok {
+			return 0, "", argErrorf(name, "%s is not the name of a pool on the table.", name)
+		}
+		return pool.Total(), fmt.Sprintf(" (pool %s total %d)", name, pool.Total()), nil
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, "", argErrorf(arg, "%s is not a number or pool:[pool name].", quoteArg(arg))
+	}
+	return n, "", nil
+}
+
+func listCounters(table *dice.Table) string {
+	if len(table.Counters) == 0 {
+		return "There are no counters on the table.\n"
+	}
+	names := make([]string, 0, len(table.Counters))
+	for name := range table.Counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return_str := "Counters:\n"
+	for _, name := range names {
+		return_str += fmt.Sprintf("%s: %s\n", name, table.Counters[name])
+	}
+	return return_str
+}
+
+func (s *session) counterNames() []string {
+	names := make([]string, 0, len(s.table.Counters))
+	for name := range s.table.Counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
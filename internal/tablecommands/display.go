@@ -0,0 +1,64 @@
+package tablecommands
+
+import (
+	"dicetable/pkg/dice"
+	"errors"
+	"fmt"
+)
+
+func sortPool(s *session, args []string) (string, error) {
+	// Put the dice in pools in order of their faces. sort pool [pool names...] {optional} [asc/desc]
+	if len(args) < 2 || args[0] != "pool" {
+		return "", errors.New("sort command format is sort pool [pool names...] {optional} [asc/desc]")
+	}
+	names := args[1:]
+	descending := false
+	switch names[len(names)-1] {
+	case "asc":
+		names = names[:len(names)-1]
+	case "desc":
+		descending = true
+		names = names[:len(names)-1]
+	}
+	if len(names) == 0 {
+		return "", errors.New("Not enough arguments provided. sort pool [pool names...] {optional} [asc/desc]")
+	}
+
+	return_str := "Sorted:\n"
+	names, errs := s.selectPools(names)
+	for _, name := range names {
+		pool, ok := s.table.Pools[name]
+		if !ok {
+			errs = append(errs, argErrorf(name, "%s is not the name of a pool on the table.", name))
+			continue
+		}
+		pool.Sort(descending)
+		return_str += fmt.Sprintf("Pool %s: %d Total: %d\n", name, pool.List(), pool.Total())
+	}
+	if return_str == "Sorted:\n" {
+		return "", joinErrors(errs)
+	}
+	return return_str, joinErrors(errs)
+}
+
+func (s *session) takeDisplay(args []string) ([]string, string) {
+	// Take --list, --sorted or --grouped out of the arguments of a command that shows dice.
+	// Without one the display setting is used
+	display := s.display
+	var rest []string
+	for _, arg := range args {
+		switch arg {
+		case "--list":
+			display = dice.ShowList
+		case "--sorted":
+			display = dice.ShowSorted
+		case "--grouped":
+			display = dice.ShowGrouped
+		case "--auto":
+			display = dice.ShowAuto
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest, display
+}
@@ -0,0 +1,310 @@
+package tablecommands
+
+import (
+	"bufio"
+	"dicetable/pkg/dice"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// An Event is one line of a table's log. It records a command that was run and how it changed the table.
+// Manual is set when the faces were entered by hand rather than rolled.
+type Event struct {
+	Time    time.Time    `json:"time"`
+	Table   string       `json:"table"`
+	Command string       `json:"command"`
+	Args    []string     `json:"args"`
+	Seed    int64        `json:"seed"`
+	Manual  bool         `json:"manual,omitempty"`
+	Changes []PoolChange `json:"changes,omitempty"`
+	// Changes to the counters and the initiative order on the table
+	Counters   []CounterChange   `json:"counters,omitempty"`
+	Initiative *InitiativeChange `json:"initiative,omitempty"`
+	Output     string            `json:"output,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// A PoolChange is the state of a pool before and after a command. Before is nil if the pool was added
+// and After is nil if it was removed.
+// Rolled is set if the pool was rolled, so its history can be rebuilt.
+type PoolChange struct {
+	Pool   string     `json:"pool"`
+	Before *PoolState `json:"before"`
+	After  *PoolState `json:"after"`
+	Rolled bool       `json:"rolled,omitempty"`
+}
+
+// The state of a pool. History is only recorded in snapshots and when a pool is added to a table,
+// other events rebuild it from Rolled
+type PoolState struct {
+	Sides       int      `json:"sides"`
+	Faces       []int    `json:"faces"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	History     [][]int  `json:"history,omitempty"`
+}
+
+// A CounterChange is the state of a counter before and after a command, nil if it was added or removed
+type CounterChange struct {
+	Counter string        `json:"counter"`
+	Before  *CounterState `json:"before"`
+	After   *CounterState `json:"after"`
+}
+
+type CounterState struct {
+	Value int  `json:"value"`
+	Min   *int `json:"min,omitempty"`
+	Max   *int `json:"max,omitempty"`
+	Clock bool `json:"clock,omitempty"`
+}
+
+func newCounterState(counter *dice.Counter) *CounterState {
+	if counter == nil {
+		return nil
+	}
+	copied := counter.Copy()
+	return &CounterState{Value: copied.Value, Min: copied.Min, Max: copied.Max, Clock: copied.Clock}
+}
+
+func (state *CounterState) Counter() *dice.Counter {
+	counter := dice.Counter{Value: state.Value, Min: state.Min, Max: state.Max, Clock: state.Clock}
+	return counter.Copy()
+}
+
+// An InitiativeChange is the initiative order before and after a command, nil if it was started or ended
+type InitiativeChange struct {
+	Before *InitiativeState `json:"before"`
+	After  *InitiativeState `json:"after"`
+}
+
+type InitiativeState struct {
+	Combatants []CombatantState `json:"combatants"`
+	Turn       int              `json:"turn"`
+	Round      int              `json:"round"`
+}
+
+type CombatantState struct {
+	Name     string `json:"name"`
+	Modifier int    `json:"modifier"`
+	Roll     int    `json:"roll"`
+	TieBreak int    `json:"tie_break"`
+}
+
+func newInitiativeState(order *dice.Initiative) *InitiativeState {
+	if order == nil {
+		return nil
+	}
+	state := &InitiativeState{Combatants: []CombatantState{}, Turn: order.Turn, Round: order.Round}
+	for _, c := range order.Combatants {
+		state.Combatants = append(state.Combatants, CombatantState{Name: c.Name, Modifier: c.Modifier, Roll: c.Roll, TieBreak: c.TieBreak})
+	}
+	return state
+}
+
+func (state *InitiativeState) Initiative() *dice.Initiative {
+	order := &dice.Initiative{Turn: state.Turn, Round: state.Round}
+	for _, c := range state.Combatants {
+		order.Combatants = append(order.Combatants, dice.Combatant{Name: c.Name, Modifier: c.Modifier, Roll: c.Roll, TieBreak: c.TieBreak})
+	}
+	return order
+}
+
+func newPoolState(pool *dice.Pool) *PoolState {
+	if pool == nil {
+		return nil
+	}
+	return &PoolState{Sides: pool.Sides, Faces: pool.List(), Description: pool.Description, Tags: pool.Tags}
+}
+
+func (state *PoolState) Pool() *dice.Pool {
+	// Create a pool with the dice facing the way they were when the state was recorded
+	pool := dice.CreatePool(len(state.Faces), state.Sides)
+	pool.Description = state.Description
+	pool.Tags = state.Tags
+	pool.History = state.History
+	for n, face := range state.Faces {
+		pool.Dice[n].Top = face
+	}
+	return pool
+}
+
+func (s *session) logEvents(active *dice.Table, command string, args []string, seed int64, changes []tableChange, output string, err error) {
+	// Write an event to the log of the table the command was run on and to the log of every other table it changed.
+	// The output and error of the command are only written to the log of the table it was run on
+	newEvent := func(table string, pools []poolChange, counters []counterChange, initiative *initiativeChange) Event {
+		event := Event{Time: time.Now(), Table: table, Command: command, Args: args, Seed: seed, Manual: manual[command]}
+		for _, change := range pools {
+			rolled := change.Before != nil && change.After != nil && !sameHistory(change.Before, change.After)
+			after := newPoolState(change.After)
+			// A pool that was renamed or moved keeps its history, so it goes in with the pool
+			if change.Before == nil && after != nil {
+				after.History = change.After.History
+			}
+			event.Changes = append(event.Changes, PoolChange{Pool: change.Name, Before: newPoolState(change.Before), After: after, Rolled: rolled})
+		}
+		for _, change := range counters {
+			event.Counters = append(event.Counters, CounterChange{Counter: change.Name, Before: newCounterState(change.Before), After: newCounterState(change.After)})
+		}
+		if initiative != nil {
+			event.Initiative = &InitiativeChange{Before: newInitiativeState(initiative.Before), After: newInitiativeState(initiative.After)}
+		}
+		return event
+	}
+
+	logged_active := false
+	for _, change := range changes {
+		table, ok := s.tables[change.Table]
+		if !ok {
+			table = &dice.Table{Pools: make(map[string]*dice.Pool), Name: change.Table}
+		}
+		event := newEvent(change.Table, change.Pools, change.Counters, change.Initiative)
+		if table == active {
+			event.Output = output
+			if err != nil {
+				event.Error = err.Error()
+			}
+			logged_active = true
+		}
+		s.writeEvent(table, event)
+	}
+
+	if !logged_active {
+		event := newEvent(active.Name, nil, nil, nil)
+		event.Output = output
+		if err != nil {
+			event.Error = err.Error()
+		}
+		s.writeEvent(active, event)
+	}
+}
+
+func (s *session) writeEvent(table *dice.Table, event Event) {
+	// Write an event to the session's logger. A log that can't be written is a warning, not an error of the command
+	// Commands run by a preview didn't happen, so they mustn't end up in the log
+	if s.previewing {
+		return
+	}
+	err := s.logger.write(table, event)
+	if err != nil && s.warnings != nil {
+		fmt.Fprintln(s.warnings, err)
+	}
+}
+
+func Replay(events io.Reader, table *dice.Table) (int, error) {
+	// Rebuild a table from its log by applying the changes of each event in order to an empty table.
+	// Returns the number of events that were read
+	replayed := dice.Table{Pools: make(map[string]*dice.Pool), Name: table.Name}
+	reader := bufio.NewReader(events)
+	count := 0
+	line_number := 0
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return count, err
+		}
+		line_number++
+		if strings.TrimSpace(line) != "" {
+			var event Event
+			if decode_err := json.Unmarshal([]byte(line), &event); decode_err != nil {
+				return count, fmt.Errorf("line %d of the log could not be read: %v", line_number, decode_err)
+			}
+			for _, change := range event.Changes {
+				if change.After == nil {
+					delete(replayed.Pools, change.Pool)
+					continue
+				}
+				// Keep the history of the pool unless the event has its own, then add the roll if there was one
+				pool := change.After.Pool()
+				if previous, ok := replayed.Pools[change.Pool]; ok && change.After.History == nil {
+					pool.History = previous.History
+				}
+				if change.Rolled {
+					pool.Record()
+				}
+				replayed.Pools[change.Pool] = pool
+			}
+			for _, change := range event.Counters {
+				if change.After == nil {
+					delete(replayed.Counters, change.Counter)
+					continue
+				}
+				replayed.AddCounter(change.Counter, change.After.Counter())
+			}
+			if event.Initiative != nil {
+				replayed.Initiative = nil
+				if event.Initiative.After != nil {
+					replayed.Initiative = event.Initiative.After.Initiative()
+				}
+			}
+			count++
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+
+	// Only change the table once the whole log has been read
+	table.Clear()
+	for name, pool := range replayed.Pools {
+		table.Pools[name] = pool
+	}
+	for name := range table.Counters {
+		delete(table.Counters, name)
+	}
+	for name, counter := range replayed.Counters {
+		table.AddCounter(name, counter)
+	}
+	table.Initiative = replayed.Initiative
+	return count, nil
+}
+
+func replay(s *session, args []string) (string, error) {
+	// Rebuild the table from a log file. replay [log file]
+	// Without a file the table's own log is used
+	var path string
+	switch len(args) {
+	case 0:
+		if s.logger == nil {
+			return "", errors.New("This table has no log to replay. Use replay [log file].")
+		}
+		var err error
+		path, err = s.logger.path(s.table.Name)
+		if err != nil {
+			return "", err
+		}
+	case 1:
+		path = args[0]
+	default:
+		return "", errors.New("replay command format is replay {optional} [log file]")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	count, err := Replay(file, s.table)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(s.table.Pools))
+	for name := range s.table.Pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return_str := fmt.Sprintf("Replayed %d events from %s.\n", count, path)
+	for _, name := range names {
+		pool := s.table.Pools[name]
+		return_str += fmt.Sprintf("Pool %s: %d Total: %d\n", name, pool.List(), pool.Total())
+	}
+	return return_str, nil
+}
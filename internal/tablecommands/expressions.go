@@ -0,0 +1,83 @@
+package tablecommands
+
+import (
+	"dicetable/internal/report"
+	"dicetable/pkg/dice"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+func rollExpression(s *session, args []string) (string, error) {
+	// Roll a dice expression without adding any dice to the table. r [expression]
+	// The roll is kept so it can be added to the table afterwards with capture
+	if len(args) < 1 {
+		return "", errors.New("Not enough arguments provided. r [dice expression] like r 1d20+4")
+	}
+	expression, err := dice.ParseExpression(strings.Join(args, " "))
+	if err != nil {
+		return "", err
+	}
+	result := expression.Roll()
+	s.last_roll = &result
+	rolled := report.NewRoll(dice.CurrentSeed(), s.table.Name)
+	rolled.AddExpression(result.Expression.Text, result)
+	return s.formatRoll(result.String()+"\n", rolled), nil
+}
+
+func isExpression(args []string) bool {
+	_, err := dice.ParseExpression(strings.Join(args, " "))
+	return err == nil
+}
+
+func capture(s *session, args []string) (string, error) {
+	// Add the dice from the last expression rolled to the table. capture [pool name]
+	// Each XdY in the expression becomes a pool of the dice it kept. If there is more than one they are numbered name-1, name-2...
+	if len(args) != 1 {
+		return "", errors.New("capture command format is capture [pool name]")
+	}
+	if s.last_roll == nil {
+		return "", errors.New("Nothing has been rolled to capture. Roll an expression first like r 2d6.")
+	}
+
+	var terms []dice.TermResult
+	for _, term := range s.last_roll.Terms {
+		if term.Term.Count > 0 {
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) == 0 {
+		return "", fmt.Errorf("%s has no dice to capture.", s.last_roll.Expression.Text)
+	}
+
+	names := make([]string, len(terms))
+	for n := range terms {
+		names[n] = args[0]
+		if len(terms) > 1 {
+			names[n] = fmt.Sprintf("%s-%d", args[0], n+1)
+		}
+		if _, ok := s.table.Pools[names[n]]; ok {
+			return "", argErrorf(names[n], "There is already a pool named %s on the table.", names[n])
+		}
+	}
+
+	return_str := "Captured:\n"
+	for n, term := range terms {
+		// Dice dropped by kh, kl, dh or dl weren't part of the roll, so they aren't captured
+		var faces []int
+		for i, face := range term.Faces {
+			if term.Kept[i] {
+				faces = append(faces, face)
+			}
+		}
+		pool := dice.CreatePool(len(faces), term.Term.Sides)
+		for i, face := range faces {
+			pool.Dice[i].Top = face
+		}
+		// The roll is the pool's first, so it goes in the history and the log like a pool that was rolled
+		pool.Record()
+		s.table.Pools[names[n]] = pool
+		return_str += fmt.Sprintf("Pool %s: %d Total: %d\n", names[n], pool.List(), pool.Total())
+	}
+	return return_str, nil
+}
@@ -0,0 +1,30 @@
+package tablecommands
+
+import (
+	"dicetable/internal/report"
+	"strings"
+)
+
+// The format new sessions print rolls in. Changed with SetFormat or the format setting.
+// Empty uses the config file, or text if it doesn't set one
+var output_format string
+
+func SetFormat(format string) error {
+	if err := report.Check(format); err != nil {
+		return err
+	}
+	output_format = format
+	return nil
+}
+
+func (s *session) formatRoll(text string, rolled report.Roll) string {
+	// Return the text of a roll, or the roll written in the session's format
+	if s.format == report.Text || s.format == "" {
+		return text
+	}
+	var out strings.Builder
+	if err := report.Write(&out, s.format, rolled); err != nil {
+		return text
+	}
+	return out.String()
+}
@@ -0,0 +1,119 @@
+package tablecommands
+
+import (
+	"dicetable/pkg/dice"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func history(s *session, args []string) (string, error) {
+	// List the previous rolls of a pool, oldest first. history pool [pool name] {optional} [number of rolls]
+	if len(args) < 2 || len(args) > 3 || args[0] != "pool" {
+		return "", errors.New("history command format is history pool [pool name] {optional} [number of rolls]")
+	}
+	pool, ok := s.table.Pools[args[1]]
+	if !ok {
+		return "", argErrorf(args[1], "%s is not the name of a pool on the table.", args[1])
+	}
+	rolls := pool.History
+	if len(args) == 3 {
+		count, err := strconv.Atoi(args[2])
+		if err != nil || count < 1 {
+			return "", argErrorf(args[2], "%s is not a number of rolls", args[2])
+		}
+		if count < len(rolls) {
+			rolls = rolls[len(rolls)-count:]
+		}
+	}
+	if len(rolls) == 0 {
+		return fmt.Sprintf("Pool %s has not been rolled yet.\n", args[1]), nil
+	}
+
+	return_str := fmt.Sprintf("History of pool %s:\n", args[1])
+	first := len(pool.History) - len(rolls) + 1
+	for n, roll := range rolls {
+		total := 0
+		for _, face := range roll {
+			total += face
+		}
+		return_str += fmt.Sprintf("%d: %d Total: %d\n", first+n, roll, total)
+	}
+	return return_str, nil
+}
+
+func stats(s *session, args []string) (string, error) {
+	// Compare the rolls of each pool to what fair dice would roll.
+	// stats shows a line for each pool, stats pool [pool names...] shows the details
+	if len(args) == 0 {
+		names := make([]string, 0, len(s.table.Pools))
+		for name := range s.table.Pools {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return_str := "Stats:\n"
+		for _, name := range names {
+			stats := s.table.Pools[name].Stats()
+			if stats.Rolls == 0 {
+				return_str += fmt.Sprintf("%s (d%d): not rolled yet\n", name, stats.Sides)
+				continue
+			}
+			return_str += fmt.Sprintf("%s (d%d): %d rolls, average die %.2f (fair %.2f), %s\n",
+				name, stats.Sides, stats.Rolls, stats.Mean, stats.Expected, verdict(stats))
+		}
+		return return_str, nil
+	}
+
+	if args[0] != "pool" || len(args) < 2 {
+		return "", errors.New("stats command format is stats or stats pool [pool names...]")
+	}
+	return_str := ""
+	names, errs := s.selectPools(args[1:])
+	for _, name := range names {
+		pool, ok := s.table.Pools[name]
+		if !ok {
+			errs = append(errs, argErrorf(name, "%s is not the name of a pool on the table.", name))
+			continue
+		}
+		return_str += describeStats(name, pool.Stats())
+	}
+	return return_str, joinErrors(errs)
+}
+
+func describeStats(name string, stats dice.PoolStats) string {
+	if stats.Rolls == 0 {
+		return fmt.Sprintf("Pool %s (d%d) has not been rolled yet.\n", name, stats.Sides)
+	}
+	faces := make([]string, len(stats.Counts))
+	for n, count := range stats.Counts {
+		faces[n] = fmt.Sprintf("%d×%d", n+1, count)
+	}
+
+	return_str := fmt.Sprintf("Pool %s (d%d): %d rolls of %d dice\n", name, stats.Sides, stats.Rolls, stats.Dice)
+	return_str += fmt.Sprintf("  Average die: %.2f (fair dice average %.2f)\n", stats.Mean, stats.Expected)
+	return_str += fmt.Sprintf("  Average total: %.2f (fair dice average %.2f)\n", stats.TotalMean, stats.TotalExpected)
+	return_str += fmt.Sprintf("  Faces: %s\n", strings.Join(faces, " "))
+	return_str += fmt.Sprintf("  Longest streak above average: %d rolls, below average: %d rolls\n", stats.HighStreak, stats.LowStreak)
+	if stats.Sides > 1 {
+		return_str += fmt.Sprintf("  Chi-square %.2f with %d degrees of freedom, p = %.3f: %s\n", stats.ChiSquare, stats.Sides-1, stats.PValue, verdict(stats))
+	}
+	return return_str
+}
+
+func verdict(stats dice.PoolStats) string {
+	// Say in words whether the dice are rolling like fair dice
+	switch {
+	case stats.Sides < 2:
+		return "a one sided die can only roll a 1"
+	case stats.Dice < 5*stats.Sides:
+		return "not enough rolls to tell yet"
+	case stats.PValue < 0.01:
+		return "rolling very unusually for fair dice"
+	case stats.PValue < 0.05:
+		return "rolling somewhat unusually for fair dice"
+	}
+	return "rolling like fair dice"
+}
@@ -0,0 +1,214 @@
+package tablecommands
+
+import (
+	"dicetable/pkg/dice"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+func initiativeCommand(s *session, args []string) (string, error) {
+	// Keep the turn order of a fight. init roll [names:modifiers...], init add [names:modifiers...],
+	// init remove [names...], init next, init prev, init delay {optional} [combatant], init end, init view
+	if len(args) == 0 {
+		args = []string{"view"}
+	}
+
+	switch args[0] {
+	case "roll":
+		if len(args) < 2 {
+			return "", errors.New("init roll format is init roll [combatant names:modifiers...]")
+		}
+		names, modifiers, err := readCombatants(args[1:])
+		if err != nil {
+			return "", err
+		}
+		order, err := dice.RollInitiative(names, modifiers)
+		if err != nil {
+			return "", err
+		}
+		s.table.Initiative = order
+		return "Rolled initiative.\n" + describeInitiative(order), nil
+	case "add":
+		if len(args) < 2 {
+			return "", errors.New("init add format is init add [combatant names:modifiers...]")
+		}
+		if s.table.Initiative == nil {
+			return "", errors.New("There is no initiative order to add to. Use init roll to start one.")
+		}
+		names, modifiers, err := readCombatants(args[1:])
+		if err != nil {
+			return "", err
+		}
+		return_str := ""
+		var errs []error
+		for n, name := range names {
+			combatant, err := s.table.Initiative.Add(name, modifiers[n])
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			return_str += fmt.Sprintf("Added %s with %s.\n", name, describeRoll(combatant))
+		}
+		if return_str == "" {
+			return "", joinErrors(errs)
+		}
+		return return_str + describeInitiative(s.table.Initiative), joinErrors(errs)
+	case "remove":
+		if len(args) < 2 {
+			return "", errors.New("init remove format is init remove [combatant names...]")
+		}
+		if s.table.Initiative == nil {
+			return "", errors.New("There is no initiative order. Use init roll to start one.")
+		}
+		return_str := ""
+		var errs []error
+		for _, name := range args[1:] {
+			err := s.table.Initiative.Remove(name)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			return_str += fmt.Sprintf("Removed %s from the initiative order.\n", name)
+		}
+		if return_str == "" {
+			return "", joinErrors(errs)
+		}
+		return return_str + describeInitiative(s.table.Initiative), joinErrors(errs)
+	case "next":
+		return nextTurn(s, args[1:])
+	case "prev":
+		return prevTurn(s, args[1:])
+	case "delay":
+		return delayTurn(s, args[1:])
+	case "end":
+		if s.table.Initiative == nil {
+			return "", errors.New("There is no initiative order to end.")
+		}
+		rounds := s.table.Initiative.Round
+		s.table.Initiative = nil
+		return fmt.Sprintf("Ended initiative after %d rounds.\n", rounds), nil
+	case "view":
+		if s.table.Initiative == nil {
+			return "There is no initiative order. Use init roll to start one.\n", nil
+		}
+		return describeInitiative(s.table.Initiative), nil
+	}
+	return "", errors.New("init command format is init [roll/add/remove/next/prev/delay/end/view] [combatants]")
+}
+
+func nextTurn(s *session, args []string) (string, error) {
+	// Move to the next combatant's turn. next
+	if len(args) != 0 {
+		return "", errors.New("next command format is next")
+	}
+	order := s.table.Initiative
+	if order == nil || len(order.Combatants) == 0 {
+		return "", errors.New("There is no initiative order. Use init roll to start one.")
+	}
+	round := order.Round
+	order.Next()
+	return_str := ""
+	if order.Round != round {
+		return_str = fmt.Sprintf("Round %d begins.\n", order.Round)
+	}
+	return return_str + describeTurn(order), nil
+}
+
+func prevTurn(s *session, args []string) (string, error) {
+	// Move back to the previous combatant's turn. prev
+	if len(args) != 0 {
+		return "", errors.New("prev command format is prev")
+	}
+	order := s.table.Initiative
+	if order == nil || len(order.Combatants) == 0 {
+		return "", errors.New("There is no initiative order. Use init roll to start one.")
+	}
+	if order.Turn == 0 && order.Round <= 1 {
+		return "", errors.New("It is already the first turn of the fight.")
+	}
+	order.Prev()
+	return describeTurn(order), nil
+}
+
+func delayTurn(s *session, args []string) (string, error) {
+	// Move the combatant whose turn it is to after another combatant, or after the next one. delay {optional} [combatant]
+	if len(args) > 1 {
+		return "", errors.New("delay command format is delay {optional} [combatant to go after]")
+	}
+	order := s.table.Initiative
+	if order == nil || len(order.Combatants) == 0 {
+		return "", errors.New("There is no initiative order. Use init roll to start one.")
+	}
+	delayed := order.Current().Name
+	after := ""
+	if len(args) == 1 {
+		after = args[0]
+	} else if order.Turn+1 < len(order.Combatants) {
+		after = order.Combatants[order.Turn+1].Name
+	}
+	err := order.Delay(after)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s delays until after %s.\n", delayed, after) + describeTurn(order), nil
+}
+
+func readCombatants(args []string) ([]string, []int, error) {
+	// Read combatants written as name:modifier. The modifier can be left off
+	var names []string
+	var modifiers []int
+	for _, arg := range args {
+		name, modifier_str, ok := splitNameArg(arg)
+		modifier := 0
+		if ok {
+			var err error
+			modifier, err = strconv.Atoi(modifier_str)
+			if err != nil {
+				return nil, nil, argErrorf(modifier_str, "%s is not a modifier for %s.", quoteArg(modifier_str), name)
+			}
+		} else {
+			name = arg
+		}
+		if name == "" {
+			return nil, nil, errors.New("Combatants need a name. Format is [name]:[modifier]")
+		}
+		names = append(names, name)
+		modifiers = append(modifiers, modifier)
+	}
+	return names, modifiers, nil
+}
+
+func describeRoll(combatant dice.Combatant) string {
+	if combatant.Modifier == 0 {
+		return fmt.Sprintf("%d", combatant.Total())
+	}
+	return fmt.Sprintf("%d (%d%+d)", combatant.Total(), combatant.Roll, combatant.Modifier)
+}
+
+func describeTurn(order *dice.Initiative) string {
+	return fmt.Sprintf("Round %d: %s's turn.\n", order.Round, order.Current().Name)
+}
+
+func describeInitiative(order *dice.Initiative) string {
+	// List the combatants in turn order with a marker at the one whose turn it is
+	return_str := fmt.Sprintf("Round %d:\n", order.Round)
+	for n, combatant := range order.Combatants {
+		marker := " "
+		if n == order.Turn {
+			marker = ">"
+		}
+		return_str += fmt.Sprintf("%s %s: %s\n", marker, combatant.Name, describeRoll(combatant))
+	}
+	return return_str
+}
+
+func (s *session) combatantNames() []string {
+	var names []string
+	if s.table.Initiative != nil {
+		for _, combatant := range s.table.Initiative.Combatants {
+			names = append(names, combatant.Name)
+		}
+	}
+	return names
+}
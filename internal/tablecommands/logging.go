@@ -0,0 +1,356 @@
+package tablecommands
+
+import (
+	"dicetable/pkg/dice"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogConfig controls where the logs of each table are kept and how long they are kept for
+type LogConfig struct {
+	Dir      string        // the directory logs and the prompt history are kept in
+	Disabled bool          // don't write logs or history at all
+	MaxSize  int64         // start a new log file once the current one reaches this many bytes. 0 never does
+	Daily    bool          // start a new log file each day
+	MaxFiles int           // how many old log files to keep for each table. 0 keeps all of them
+	MaxAge   time.Duration // delete old log files once they are older than this. 0 keeps them forever
+}
+
+// How old log files are named, after the table name and before .jsonl
+const rotatedFormat = "20060102-150405.000"
+
+func DefaultLogConfig() LogConfig {
+	// Logs go in ~/dice-logs and are rotated every 10MB keeping the last 10 files
+	dir := "dice-logs"
+	if home, err := os.UserHomeDir(); err == nil {
+		dir = filepath.Join(home, "dice-logs")
+	}
+	return LogConfig{Dir: dir, MaxSize: 10 << 20, MaxFiles: 10}
+}
+
+// A Logger keeps the log of each table a session has run commands on open in the directory of its config.
+// It can be shared by sessions running at the same time. A nil Logger logs nothing
+type Logger struct {
+	config LogConfig
+	// guards logs and the writes to each of them
+	mutex sync.Mutex
+	logs  map[string]*EventLog
+}
+
+func NewLogger(config LogConfig) (*Logger, error) {
+	// Make the directory the logs are kept in. Returns nil if logging is turned off in the config
+	if config.Disabled {
+		return nil, nil
+	}
+	err := os.MkdirAll(config.Dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{config: config, logs: make(map[string]*EventLog)}, nil
+}
+
+func (logger *Logger) Dir() string {
+	// The directory the logs and the prompt history are kept in, or an empty string if nothing is logged
+	if logger == nil {
+		return ""
+	}
+	return logger.config.Dir
+}
+
+func (logger *Logger) Open(name string) error {
+	// Start the log of a table if it isn't open already
+	if logger == nil {
+		return nil
+	}
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	_, err := logger.logFor(name)
+	return err
+}
+
+func (logger *Logger) Close() error {
+	// Close the log of every table. Logs are opened again if more events are written
+	if logger == nil {
+		return nil
+	}
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	var errs []error
+	for name, event_log := range logger.logs {
+		if err := event_log.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(logger.logs, name)
+	}
+	return joinErrors(errs)
+}
+
+func (logger *Logger) path(name string) (string, error) {
+	// The file the events of a table are being written to
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	event_log, err := logger.logFor(name)
+	if err != nil {
+		return "", err
+	}
+	return event_log.path, nil
+}
+
+func (logger *Logger) logFor(name string) (*EventLog, error) {
+	// Return the log of a table, opening it if it isn't open yet. The mutex must be held
+	if event_log, ok := logger.logs[name]; ok {
+		return event_log, nil
+	}
+	event_log, err := openEventLog(logger.config.Dir, logFileName(name), logger.config)
+	if err != nil {
+		return nil, err
+	}
+	logger.logs[name] = event_log
+	return event_log, nil
+}
+
+func (logger *Logger) write(table *dice.Table, event Event) error {
+	// Add an event to the log of a table
+	if logger == nil {
+		return nil
+	}
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	event_log, err := logger.logFor(table.Name)
+	if err != nil {
+		return err
+	}
+	return event_log.write(event, table)
+}
+
+// The logger used by sessions that aren't given one. Set by StartLog, events are not written anywhere until it is called
+var default_logger *Logger
+
+// The config used by StartLog. DefaultLogConfig is used if it was never set
+var log_config *LogConfig
+
+func SetLogConfig(config LogConfig) {
+	log_config = &config
+}
+
+func StartLog(name string) (string, error) {
+	// start a new log file for the table if there is not one already.
+	// Each command run is added to the log of the tables it was run on as a line of JSON.
+	// Returns the directory the logs are kept in, or an empty string if logging is turned off
+	config := DefaultLogConfig()
+	if log_config != nil {
+		config = *log_config
+	}
+	default_logger.Close()
+	default_logger = nil
+
+	logger, err := NewLogger(config)
+	if err != nil {
+		return "", err
+	}
+	err = logger.Open(name)
+	if err != nil {
+		return "", err
+	}
+	default_logger = logger
+	return logger.Dir(), nil
+}
+
+func logFileName(name string) string {
+	// Replace characters that can't be in a file name. Tables without a name are logged to NoName
+	if name == "" {
+		return "NoName"
+	}
+	return strings.NewReplacer("/", "_", "\\", "_", string(os.PathSeparator), "_").Replace(name)
+}
+
+// An EventLog adds events to a table's log file, moving the file aside and starting a new one when it
+// gets too big or a new day starts, and deleting old files past the retention limits
+type EventLog struct {
+	config  LogConfig
+	dir     string
+	base    string
+	path    string
+	file    *os.File
+	size    int64
+	started time.Time
+}
+
+func openEventLog(dir string, base string, config LogConfig) (*EventLog, error) {
+	event_log := &EventLog{config: config, dir: dir, base: base, path: filepath.Join(dir, base+".jsonl")}
+	err := event_log.open()
+	if err != nil {
+		return nil, err
+	}
+	return event_log, event_log.prune()
+}
+
+func (event_log *EventLog) open() error {
+	file, err := os.OpenFile(event_log.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	event_log.file = file
+	event_log.size = info.Size()
+	event_log.started = time.Now()
+	if info.Size() > 0 {
+		event_log.started = info.ModTime()
+	}
+	return nil
+}
+
+func (event_log *EventLog) Close() error {
+	return event_log.file.Close()
+}
+
+func (event_log *EventLog) write(event Event, table *dice.Table) error {
+	// Add an event to the end of the log as a single line of JSON.
+	// When a new file is started it begins with a snapshot of the table so it can be replayed on its own
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if event_log.needsRotation(len(line)) {
+		err = event_log.rotate()
+		if err != nil {
+			return err
+		}
+		if table != nil {
+			err = event_log.writeLine(snapshotEvent(table))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	n, err := event_log.file.Write(line)
+	event_log.size += int64(n)
+	return err
+}
+
+func (event_log *EventLog) writeLine(event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	n, err := event_log.file.Write(append(line, '\n'))
+	event_log.size += int64(n)
+	return err
+}
+
+func snapshotEvent(table *dice.Table) Event {
+	// An event that adds every pool and counter and the initiative order on the table as it is now
+	names := make([]string, 0, len(table.Pools))
+	for name := range table.Pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	event := Event{Time: time.Now(), Table: table.Name, Command: "snapshot"}
+	for _, name := range names {
+		state := newPoolState(table.Pools[name])
+		state.History = table.Pools[name].History
+		event.Changes = append(event.Changes, PoolChange{Pool: name, After: state})
+	}
+	counter_names := make([]string, 0, len(table.Counters))
+	for name := range table.Counters {
+		counter_names = append(counter_names, name)
+	}
+	sort.Strings(counter_names)
+	for _, name := range counter_names {
+		event.Counters = append(event.Counters, CounterChange{Counter: name, After: newCounterState(table.Counters[name])})
+	}
+	if table.Initiative != nil {
+		event.Initiative = &InitiativeChange{After: newInitiativeState(table.Initiative)}
+	}
+	return event
+}
+
+func (event_log *EventLog) needsRotation(n int) bool {
+	if event_log.size == 0 {
+		return false
+	}
+	if event_log.config.MaxSize > 0 && event_log.size+int64(n) > event_log.config.MaxSize {
+		return true
+	}
+	if event_log.config.Daily {
+		y1, m1, d1 := event_log.started.Date()
+		y2, m2, d2 := time.Now().Date()
+		return y1 != y2 || m1 != m2 || d1 != d2
+	}
+	return false
+}
+
+func (event_log *EventLog) rotate() error {
+	// Move the current file aside with the time in its name and start a new one
+	err := event_log.file.Close()
+	if err != nil {
+		return err
+	}
+	rotated := filepath.Join(event_log.dir, event_log.base+"."+time.Now().Format(rotatedFormat)+".jsonl")
+	err = os.Rename(event_log.path, rotated)
+	if err != nil {
+		return err
+	}
+	err = event_log.open()
+	if err != nil {
+		return err
+	}
+	return event_log.prune()
+}
+
+func (event_log *EventLog) prune() error {
+	// Delete old log files of this table that are past the age or number of files to keep
+	entries, err := os.ReadDir(event_log.dir)
+	if err != nil {
+		return err
+	}
+
+	var old []os.DirEntry
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, event_log.base+".") || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, event_log.base+"."), ".jsonl")
+		if _, err := time.Parse(rotatedFormat, stamp); err == nil {
+			old = append(old, entry)
+		}
+	}
+	// The time in the name sorts oldest first
+	sort.Slice(old, func(i, j int) bool { return old[i].Name() < old[j].Name() })
+
+	var keep []os.DirEntry
+	for _, entry := range old {
+		if event_log.config.MaxAge > 0 {
+			info, err := entry.Info()
+			if err == nil && time.Since(info.ModTime()) > event_log.config.MaxAge {
+				if err := os.Remove(filepath.Join(event_log.dir, entry.Name())); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		keep = append(keep, entry)
+	}
+	if event_log.config.MaxFiles > 0 {
+		for len(keep) > event_log.config.MaxFiles {
+			if err := os.Remove(filepath.Join(event_log.dir, keep[0].Name())); err != nil {
+				return err
+			}
+			keep = keep[1:]
+		}
+	}
+	return nil
+}
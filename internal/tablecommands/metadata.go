@@ -0,0 +1,165 @@
+package tablecommands
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+func rename(s *session, args []string) (string, error) {
+	// Give a pool a new name. rename pool [pool name] [new name]
+	if len(args) != 3 || args[0] != "pool" {
+		return "", errors.New("rename command format is rename pool [pool name] [new name]")
+	}
+	if _, ok := s.table.Pools[args[1]]; !ok {
+		return "", argErrorf(args[1], "%s is not the name of a pool on the table.", args[1])
+	}
+	if _, ok := s.table.Pools[args[2]]; ok {
+		return "", argErrorf(args[2], "There is already a pool named %s.", args[2])
+	}
+	if args[2] == "" || strings.HasPrefix(args[2], "tag:") {
+		return "", argErrorf(args[2], "%s cannot be used as a pool name.", quoteArg(args[2]))
+	}
+	err := s.table.Rename(args[1], args[2])
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Renamed pool %s to %s.\n", args[1], args[2]), nil
+}
+
+func describe(s *session, args []string) (string, error) {
+	// Set the description of a pool. describe pool [pool name] [description...]
+	// Without a description the current one is shown, and an empty description removes it
+	if len(args) < 2 || args[0] != "pool" {
+		return "", errors.New("describe command format is describe pool [pool name] {optional} [description]")
+	}
+	pool, ok := s.table.Pools[args[1]]
+	if !ok {
+		return "", argErrorf(args[1], "%s is not the name of a pool on the table.", args[1])
+	}
+	if len(args) == 2 {
+		if pool.Description == "" {
+			return fmt.Sprintf("Pool %s has no description.\n", args[1]), nil
+		}
+		return fmt.Sprintf("%s: %s\n", args[1], pool.Description), nil
+	}
+
+	pool.Description = strings.TrimSpace(strings.Join(args[2:], " "))
+	if pool.Description == "" {
+		return fmt.Sprintf("Removed the description of pool %s.\n", args[1]), nil
+	}
+	return fmt.Sprintf("Described pool %s as: %s\n", args[1], pool.Description), nil
+}
+
+func tag(s *session, args []string) (string, error) {
+	// Add tags to a pool. tag pool [pool name] [tags...]
+	// tag pool [pool name] shows the tags of a pool and tag on its own lists every tag on the table
+	if len(args) == 0 || (len(args) == 1 && args[0] == "list") {
+		return listTags(s), nil
+	}
+	if len(args) < 2 || args[0] != "pool" {
+		return "", errors.New("tag command format is tag pool [pool name] {optional} [tags...]")
+	}
+	pool, ok := s.table.Pools[args[1]]
+	if !ok {
+		return "", argErrorf(args[1], "%s is not the name of a pool on the table.", args[1])
+	}
+	if len(args) == 2 {
+		if len(pool.Tags) == 0 {
+			return fmt.Sprintf("Pool %s has no tags.\n", args[1]), nil
+		}
+		return fmt.Sprintf("%s: %s\n", args[1], strings.Join(pool.Tags, ", ")), nil
+	}
+
+	var added []string
+	var errs []error
+	for _, t := range args[2:] {
+		if t == "" || strings.ContainsAny(t, " \t\n") {
+			errs = append(errs, argErrorf(t, "%s cannot be used as a tag.", quoteArg(t)))
+		} else if pool.Tag(t) {
+			added = append(added, t)
+		}
+	}
+	if len(added) == 0 && len(errs) > 0 {
+		return "", joinErrors(errs)
+	}
+	return fmt.Sprintf("Pool %s is tagged %s\n", args[1], strings.Join(pool.Tags, ", ")), joinErrors(errs)
+}
+
+func untag(s *session, args []string) (string, error) {
+	// Remove tags from a pool. untag pool [pool name] [tags...]
+	if len(args) < 3 || args[0] != "pool" {
+		return "", errors.New("untag command format is untag pool [pool name] [tags...]")
+	}
+	pool, ok := s.table.Pools[args[1]]
+	if !ok {
+		return "", argErrorf(args[1], "%s is not the name of a pool on the table.", args[1])
+	}
+
+	var removed []string
+	var errs []error
+	for _, t := range args[2:] {
+		if pool.Untag(t) {
+			removed = append(removed, t)
+		} else {
+			errs = append(errs, argErrorf(t, "Pool %s is not tagged %s.", args[1], quoteArg(t)))
+		}
+	}
+	if len(removed) == 0 {
+		return "", joinErrors(errs)
+	}
+	return fmt.Sprintf("Removed %s from the tags of pool %s.\n", strings.Join(removed, ", "), args[1]), joinErrors(errs)
+}
+
+func listTags(s *session) string {
+	tags := s.tagNames()
+	if len(tags) == 0 {
+		return "No pools on the table are tagged.\n"
+	}
+	return_str := "Tags:\n"
+	for _, t := range tags {
+		return_str += fmt.Sprintf("%s: %s\n", t, strings.Join(s.table.Tagged(t), ", "))
+	}
+	return return_str
+}
+
+func (s *session) tagNames() []string {
+	// Return every tag used on the active table, sorted
+	seen := make(map[string]bool)
+	var tags []string
+	for _, pool := range s.table.Pools {
+		for _, t := range pool.Tags {
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func (s *session) selectPools(names []string) ([]string, []error) {
+	// Replace each tag:[tag] in a list of pool names with the names of the pools that have the tag.
+	// A pool is only listed once even if it is picked more than once
+	var selected []string
+	var errs []error
+	seen := make(map[string]bool)
+	for _, name := range names {
+		picked := []string{name}
+		if strings.HasPrefix(name, "tag:") {
+			picked = s.table.Tagged(strings.TrimPrefix(name, "tag:"))
+			if len(picked) == 0 {
+				errs = append(errs, argErrorf(name, "No pools on the table are tagged %s.", quoteArg(strings.TrimPrefix(name, "tag:"))))
+			}
+		}
+		for _, p := range picked {
+			if !seen[p] {
+				seen[p] = true
+				selected = append(selected, p)
+			}
+		}
+	}
+	return selected, errs
+}
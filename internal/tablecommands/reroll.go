@@ -0,0 +1,83 @@
+package tablecommands
+
+import (
+	"dicetable/pkg/dice"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func reroll(s *session, args []string) (string, error) {
+	// Reroll some of the dice in a pool. reroll pool [pool name] die [positions] or reroll pool [pool name] [faces or comparison]
+	// Dice picked by their face are rerolled once unless --recursive is given, then until they stop matching
+	recursive := false
+	var rest []string
+	for _, arg := range args {
+		switch arg {
+		case "--recursive", "-r":
+			recursive = true
+		case "--once":
+			recursive = false
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	args = rest
+
+	format := "reroll command format is reroll pool [pool name] die [positions] or reroll pool [pool name] [faces or comparison] {optional} [--once/--recursive]"
+	if len(args) < 3 || args[0] != "pool" {
+		return "", errors.New(format)
+	}
+	name := args[1]
+	pool, ok := s.table.Pools[name]
+	if !ok {
+		return "", argErrorf(name, "%s is not the name of a pool on the table.", name)
+	}
+
+	var rerolls []dice.Reroll
+	var err error
+	if args[2] == "die" {
+		if len(args) != 4 {
+			return "", errors.New(format)
+		}
+		if recursive {
+			return "", errors.New("Dice picked by position can only be rerolled once. Use a face or comparison to reroll recursively.")
+		}
+		var indexes []int
+		for _, position := range strings.Split(args[3], ",") {
+			index, err := strconv.Atoi(strings.TrimSpace(position))
+			if err != nil {
+				return "", argErrorf(position, "%s is not a die position.", quoteArg(position))
+			}
+			indexes = append(indexes, index)
+		}
+		rerolls, err = pool.RerollDice(indexes)
+	} else {
+		if len(args) != 3 {
+			return "", errors.New(format)
+		}
+		condition, parse_err := dice.ParseCondition(args[2])
+		if parse_err != nil {
+			return "", parse_err
+		}
+		rerolls, err = pool.RerollWhere(condition, recursive)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if len(rerolls) == 0 {
+		return fmt.Sprintf("No dice in pool %s matched, nothing was rerolled.\n", name), nil
+	}
+	return_str := fmt.Sprintf("Rerolled pool %s:\n", name)
+	for _, r := range rerolls {
+		faces := make([]string, len(r.Faces))
+		for n, face := range r.Faces {
+			faces[n] = strconv.Itoa(face)
+		}
+		return_str += fmt.Sprintf("Die %d: %s\n", r.Index, strings.Join(faces, " -> "))
+	}
+	return_str += fmt.Sprintf("Pool %s: %d Total: %d\n", name, pool.List(), pool.Total())
+	return return_str, nil
+}
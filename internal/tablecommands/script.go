@@ -0,0 +1,161 @@
+package tablecommands
+
+import (
+	"bufio"
+	"dicetable/pkg/dice"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// How many scripts deep source can be run before giving up
+const maxSourceDepth = 16
+
+type ScriptError struct {
+	Script   string
+	Failures []LineError
+}
+
+type LineError struct {
+	Line    int
+	Command string
+	Err     error
+}
+
+func (err *ScriptError) Error() string {
+	if len(err.Failures) == 1 {
+		return fmt.Sprintf("1 command in %s failed", err.Script)
+	}
+	return fmt.Sprintf("%d commands in %s failed", len(err.Failures), err.Script)
+}
+
+func RunScript(name string, script io.Reader, table *dice.Table, out io.Writer, keep_going bool) error {
+	// Run the commands in a script against the table one line at a time and write what each one did to out.
+	// Blank lines and comments are skipped, a line ending in a backslash continues on the next line,
+	// and exit stops the script. Unless keep_going is true the script stops at the first command that fails.
+	// Returns a *ScriptError if any command failed
+	return newSession(table).runScript(name, script, out, keep_going)
+}
+
+func (s *session) runScript(name string, script io.Reader, out io.Writer, keep_going bool) error {
+	scanner := bufio.NewScanner(script)
+	script_err := &ScriptError{Script: name}
+	line_number := 0
+
+	for scanner.Scan() {
+		line_number++
+		start := line_number
+		line := scanner.Text()
+
+		// Join lines that end in an unescaped backslash
+		for continues(line) && scanner.Scan() {
+			line_number++
+			line = line[:len(line)-1] + scanner.Text()
+		}
+
+		tokens, err := tokenize(line)
+		if err == nil && len(tokens) == 0 {
+			continue
+		}
+
+		// Echo the command the same way the prompt shows it so the output reads like a session
+		fmt.Fprintf(out, "%s:> %s\n", s.table.Name, strings.TrimSpace(line))
+		output, err := s.run(line)
+		if err == ErrExit {
+			break
+		}
+		if output != "" {
+			fmt.Fprintln(out, strings.TrimRight(output, "\n"))
+		}
+		if err != nil {
+			fmt.Fprintf(out, "%s:%d: %s\n", name, start, err)
+			script_err.Failures = append(script_err.Failures, LineError{Line: start, Command: line, Err: err})
+			if !keep_going {
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(script_err.Failures) > 0 {
+		return script_err
+	}
+	return nil
+}
+
+func continues(line string) bool {
+	// Check if a line ends with a backslash that isn't itself escaped
+	count := 0
+	for n := len(line) - 1; n >= 0 && line[n] == '\\'; n-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+func source(s *session, args []string) (string, error) {
+	// Run the commands in a file against the table. source [file] [--continue]
+	var path string
+	keep_going := false
+	for _, arg := range args {
+		if arg == "--continue" || arg == "-c" {
+			keep_going = true
+		} else if path == "" {
+			path = arg
+		} else {
+			return "", errors.New("source command format is source [file] {optional} [--continue]")
+		}
+	}
+	if path == "" {
+		return "", errors.New("Not enough arguments provided. source [file] {optional} [--continue]")
+	}
+	if s.depth >= maxSourceDepth {
+		return "", fmt.Errorf("Cannot source %s, scripts are sourcing each other more than %d deep.", path, maxSourceDepth)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var out strings.Builder
+	s.depth++
+	err = s.runScript(path, file, &out, keep_going)
+	s.depth--
+	return out.String(), err
+}
+
+func Exec(commands []string, table *dice.Table, out io.Writer, errs io.Writer) ([]*dice.Table, error) {
+	// Run each command against the table in one session and write only what they output, for tools that drive a table.
+	// Every command is run even if one fails, failures are written to errs, and exit stops early.
+	// Returns every table open at the end, including ones made with table new or opened with table load,
+	// so they can all be saved, and a *ScriptError if any command failed
+	s := newSession(table)
+	script_err := &ScriptError{Script: "exec"}
+	for n, command := range commands {
+		output, err := s.run(command)
+		if err == ErrExit {
+			break
+		}
+		if output != "" {
+			fmt.Fprintln(out, strings.TrimRight(output, "\n"))
+		}
+		if err != nil {
+			fmt.Fprintln(errs, err)
+			script_err.Failures = append(script_err.Failures, LineError{Line: n + 1, Command: command, Err: err})
+		}
+	}
+
+	tables := make([]*dice.Table, 0, len(s.tables))
+	for _, name := range s.tableNames() {
+		tables = append(tables, s.tables[name])
+	}
+	if len(script_err.Failures) > 0 {
+		return tables, script_err
+	}
+	return tables, nil
+}
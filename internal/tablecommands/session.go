@@ -0,0 +1,100 @@
+package tablecommands
+
+import (
+	"dicetable/internal/lineedit"
+	"dicetable/pkg/dice"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A Session reads commands from an input, runs them against a table and writes what they did to an output.
+// Commands are logged to its logger and tables are saved to its store, either of which can be nil.
+// Nothing it does ends the program, so it can be embedded in other programs.
+// Every session rolls with the one random source of the dice package and reseeds it before each command,
+// so only one Session may run commands at a time or the seeds in the logs won't roll the same dice again
+type Session struct {
+	s   *session
+	in  io.Reader
+	out io.Writer
+}
+
+func NewSession(table *dice.Table, in io.Reader, out io.Writer, logger *Logger, store *Store) *Session {
+	// Create a session with every setting at its default. Warnings, like a log that can't be written, go to out
+	s := baseSession(table, logger, store)
+	s.warnings = out
+	return &Session{s: s, in: in, out: out}
+}
+
+func (session *Session) Table() *dice.Table {
+	// The table commands are being run against. Commands like table switch change it
+	return session.s.table
+}
+
+func (session *Session) Exec(input string) (string, error) {
+	// Run a single command. Returns what the command did and anything that went wrong separately.
+	// ErrExit is returned if the command was exit
+	return session.s.run(input)
+}
+
+func (session *Session) Run() error {
+	// Read commands until the input ends or exit is run, writing the result of each to the output.
+	// Returns nil when the session ended normally and the error otherwise
+	s := session.s
+
+	// Read commands through a line editor with history kept next to the logs and completion of commands and pool names.
+	// Only lines typed at a terminal go in the history, so piped input doesn't fill it
+	editor := lineedit.New(session.in, session.out)
+	if log_dir := s.logger.Dir(); log_dir != "" && editor.Editing {
+		editor.HistoryFile = filepath.Join(log_dir, ".history")
+	}
+	editor.Complete = s.complete
+	err := editor.LoadHistory()
+	if err != nil {
+		fmt.Fprintln(s.warnings, err)
+	}
+	s.confirm = func(question string) bool {
+		answer, err := editor.ReadLine(question + " [y/N] ")
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		return err == nil && (answer == "y" || answer == "yes")
+	}
+	defer func() { s.confirm = nil }()
+
+	for {
+		// Display the prompt made from the prompt setting
+		command, err := editor.ReadLine(s.renderPrompt())
+		if err == lineedit.ErrInterrupted {
+			continue
+		} else if err == io.EOF {
+			fmt.Fprintln(session.out, "Goodbye...")
+			return nil
+		} else if err != nil {
+			return err
+		}
+		err = editor.AddHistory(command)
+		if err != nil {
+			fmt.Fprintln(s.warnings, err)
+		}
+
+		// send the command to the session for parsing
+		output, err := s.run(command)
+		if err == ErrExit {
+			fmt.Fprintln(session.out, "Goodbye...")
+			return nil
+		}
+		fmt.Fprintln(session.out, formatResult(output, err))
+	}
+}
+
+func InteractiveLoop(table dice.Table) error {
+	// A looping function meant to simulate rolling dice at a table, reading from stdin and writing to stdout.
+	// The log config, store, config file and format set for the package are used
+	_, err := StartLog(table.Name)
+	if err != nil {
+		return err
+	}
+	session := &Session{s: newSession(&table), in: os.Stdin, out: os.Stdout}
+	return session.Run()
+}
@@ -0,0 +1,177 @@
+package tablecommands
+
+import (
+	"dicetable/pkg/dice"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNoTable is returned by Store.Load when there is no saved table with the name
+var ErrNoTable = errors.New("there is no saved table with that name")
+
+// A TableFile is a whole table as JSON, the way a Store saves it
+type TableFile struct {
+	Name       string                   `json:"name"`
+	Saved      time.Time                `json:"saved"`
+	Pools      map[string]*PoolState    `json:"pools"`
+	Counters   map[string]*CounterState `json:"counters,omitempty"`
+	Initiative *InitiativeState         `json:"initiative,omitempty"`
+}
+
+func NewTableFile(table *dice.Table) TableFile {
+	// Record everything on the table, including the history of each pool
+	file := TableFile{Name: table.Name, Saved: time.Now(), Pools: make(map[string]*PoolState), Initiative: newInitiativeState(table.Initiative)}
+	for name, pool := range table.Pools {
+		state := newPoolState(pool)
+		state.History = pool.History
+		file.Pools[name] = state
+	}
+	if len(table.Counters) > 0 {
+		file.Counters = make(map[string]*CounterState)
+		for name, counter := range table.Counters {
+			file.Counters[name] = newCounterState(counter)
+		}
+	}
+	return file
+}
+
+func (file TableFile) Table() dice.Table {
+	// Rebuild the table that was saved
+	table, _ := dice.CreateTable(nil, nil)
+	table.Name = file.Name
+	for name, state := range file.Pools {
+		table.Pools[name] = state.Pool()
+	}
+	for name, state := range file.Counters {
+		table.AddCounter(name, state.Counter())
+	}
+	if file.Initiative != nil {
+		table.Initiative = file.Initiative.Initiative()
+	}
+	return table
+}
+
+// A Store keeps tables as JSON files in a directory so they can be loaded again later
+type Store struct {
+	Dir string
+}
+
+func DefaultStoreDir() string {
+	// Tables are kept in $XDG_DATA_HOME/dicetable/tables, or ~/.local/share/dicetable/tables if XDG_DATA_HOME isn't set
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join("dicetable", "tables")
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "dicetable", "tables")
+}
+
+func (store *Store) path(name string) string {
+	return filepath.Join(store.Dir, logFileName(name)+".json")
+}
+
+func (store *Store) Save(table *dice.Table) error {
+	// Save the table under its name, replacing any table saved with the same name.
+	// The file is written next to the old one and moved over it so a failed save never loses the old table
+	err := os.MkdirAll(store.Dir, 0700)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(NewTableFile(table), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(store.Dir, ".saving-*")
+	if err != nil {
+		return err
+	}
+	_, err = temp.Write(append(data, '\n'))
+	if close_err := temp.Close(); err == nil {
+		err = close_err
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), store.path(table.Name))
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+	}
+	return err
+}
+
+func (store *Store) Load(name string) (dice.Table, error) {
+	// Load a saved table. Returns an error wrapping ErrNoTable if there isn't one with the name
+	file, err := store.read(store.path(name))
+	if os.IsNotExist(err) {
+		table, _ := dice.CreateTable(nil, nil)
+		table.Name = name
+		return table, fmt.Errorf("%s: %w", quoteArg(name), ErrNoTable)
+	}
+	if err != nil {
+		return dice.Table{}, err
+	}
+	table := file.Table()
+	table.Name = name
+	return table, nil
+}
+
+func (store *Store) read(path string) (TableFile, error) {
+	var file TableFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, err
+	}
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return file, fmt.Errorf("%s could not be read: %v", path, err)
+	}
+	return file, nil
+}
+
+func (store *Store) List() ([]TableFile, error) {
+	// Return every saved table sorted by name. A directory that doesn't exist yet has no tables
+	entries, err := os.ReadDir(store.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var files []TableFile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		file, err := store.read(filepath.Join(store.Dir, entry.Name()))
+		if err != nil {
+			return files, err
+		}
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+func (store *Store) Delete(name string) error {
+	err := os.Remove(store.path(name))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%s: %w", quoteArg(name), ErrNoTable)
+	}
+	return err
+}
+
+// The store the table save and load commands use. nil until SetStore is called
+var table_store *Store
+
+func SetStore(store *Store) {
+	table_store = store
+}
@@ -1,74 +1,119 @@
 package tablecommands
 
 import (
-	"bufio"
+	"dicetable/internal/report"
 	"dicetable/pkg/dice"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 )
 
-func InteractiveLoop(table dice.Table) {
-	// start a new log file for the table if there is not one already
+// ErrExit is returned when the exit command is run
+var ErrExit = errors.New("exit")
+
+// session holds the tables commands are run against and the state shared by the commands
+type session struct {
+	tables map[string]*dice.Table
+	// the table commands are run against
+	table *dice.Table
+	// how many scripts deep the session is running, so scripts can't source themselves forever
+	depth int
+	// asks the user a yes or no question. Commands are never confirmed if it is nil
+	confirm             func(question string) bool
+	confirm_destructive bool
+	// the last expression rolled with r, kept so it can be captured
+	last_roll *dice.ExpressionResult
+	// how the faces of rolled and viewed pools are shown: list, sorted, grouped or auto
+	display string
+	// where table save and table load keep tables. Saving and loading fail if it is nil
+	store *Store
+	// how roll and r print what they rolled: text or one of the formats of the report package
+	format string
+	// the prompt shown before each command, with {table}, {pools}, {round} and {turn} filled in
+	prompt string
+	// names that stand for a command and its first arguments, set with alias or in the config file
+	aliases map[string]string
+	// set on the copy a dry run or confirmation runs its command on. Nothing outside the copy is changed, so tables aren't saved
+	previewing bool
+	// where each command run is logged. Nothing is logged if it is nil
+	logger *Logger
+	// where problems that aren't the fault of a command, like a log that can't be written, are shown
+	warnings io.Writer
+}
 
-	log_name, err := os.UserHomeDir()
-	if err != nil {
-		log.Fatal(err)
+func newSession(table *dice.Table) *session {
+	// A session using the logger, store, config file and format set for the package
+	s := baseSession(table, default_logger, table_store)
+	s.warnings = os.Stderr
+	s.applyConfig(config_file)
+	if output_format != "" {
+		s.format = output_format
 	}
-	log_name += "/dice-logs/"
+	return s
+}
 
-	err = os.Mkdir(log_name, 0777)
-	if os.IsNotExist(err) {
-		log.Fatal(err)
-	}
+func baseSession(table *dice.Table, logger *Logger, store *Store) *session {
+	// A session with every setting at its default
+	tables := map[string]*dice.Table{table.Name: table}
+	return &session{tables: tables, table: table, confirm_destructive: true, display: dice.ShowList, store: store,
+		format: report.Text, prompt: defaultPrompt, aliases: make(map[string]string), logger: logger}
+}
 
-	if table.Name == "" {
-		log_name += "NoName"
-	} else {
-		log_name += table.Name
+func (s *session) copyTables() *session {
+	// Return a session with copies of every table so commands can be run without changing this one
+	copied := *s
+	copied.tables = make(map[string]*dice.Table)
+	for name, table := range s.tables {
+		table_copy := table.Copy()
+		copied.tables[name] = &table_copy
 	}
-	fmt.Println(log_name)
-	file, err := os.OpenFile(log_name+".txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0777)
-	if err != nil {
-		log.Fatal(err)
+	copied.table = copied.tables[s.table.Name]
+	copied.confirm = nil
+	copied.aliases = make(map[string]string)
+	for name, alias := range s.aliases {
+		copied.aliases[name] = alias
 	}
-	log.SetOutput(file)
+	return &copied
+}
 
-	// A looping function meant to simulate rolling dice at a table
-	for {
-		// Display the prompt. If the table has a name add it to the prompt
-		prompt := table.Name + ":> "
-		fmt.Print(prompt)
+// Commands made of other commands. Their changes are logged by the commands they run
+var compound = map[string]bool{
+	"source": true,
+}
 
-		// Read from stdin until there's a newline
-		reader := bufio.NewReader(os.Stdin)
-		command, err := reader.ReadString('\n')
-		command = strings.TrimSuffix(command, "\n")
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			break
-		}
+// Commands that set faces by hand instead of rolling them. Their events are marked manual in the log
+var manual = map[string]bool{
+	"set": true,
+}
 
-		// send the command to the tablecommands.ParseCommand for parsing
-		if command == "exit" {
-			fmt.Println("Goodbye...")
-			break
-		} else {
-			fmt.Println(ParseCommand(command, table))
-		}
-	}
+// Commands that remove pools or dice and are confirmed before they are run
+var destructive = map[string]bool{
+	"clear pool":     true,
+	"clear table":    true,
+	"subtract":       true,
+	"replay":         true,
+	"table delete":   true,
+	"counter delete": true,
+	"init end":       true,
 }
 
-func ParseCommand(input string, table dice.Table) string {
-	// Create a map with command names as keys and their functions as the values
-	// Return a string as an answer to the command
+func isDestructive(command string, args []string) bool {
+	if destructive[command] {
+		return true
+	}
+	return len(args) > 0 && destructive[command+" "+args[0]]
+}
 
-	command := strings.Split(input, " ")[0]
-	args := strings.Split(input, " ")[1:]
+// A map with command names as keys and their functions as the values.
+// Filled in by init because the source command runs other commands.
+var commands map[string]func(*session, []string) (string, error)
 
-	commands := map[string]func(dice.Table, []string) string{
+func init() {
+	commands = map[string]func(*session, []string) (string, error){
 		"help":     help,
 		"roll":     roll,
 		"add":      add,
@@ -76,104 +121,392 @@ func ParseCommand(input string, table dice.Table) string {
 		"view":     view,
 		"clear":    clear,
 		"set":      set,
+		"source":   source,
+		"config":   configCommand,
+		"replay":   replay,
+		"table":    tableCommand,
+		"copy":     copyPool,
+		"move":     movePool,
+		"r":        rollExpression,
+		"capture":  capture,
+		"history":  history,
+		"stats":    stats,
+		"rename":   rename,
+		"describe": describe,
+		"tag":      tag,
+		"untag":    untag,
+		"reroll":   reroll,
+		"sort":     sortPool,
+		"counter":  counterCommand,
+		"init":     initiativeCommand,
+		"next":     nextTurn,
+		"prev":     prevTurn,
+		"delay":    delayTurn,
+		"alias":    alias,
+		"unalias":  unalias,
 	}
+}
 
-	if _, ok := commands[command]; ok {
-		output := commands[command](table, args)
-		log.Println(output)
-		return output
+func RunCommand(input string, table *dice.Table) (string, error) {
+	// Run a single command against the table. Returns what the command did and anything that went wrong separately
+	output, err := newSession(table).run(input)
+	if err == ErrExit {
+		return "", nil
+	}
+	return output, err
+}
+
+func ParseCommand(input string, table dice.Table) string {
+	// Run a single command against the table and return a string as an answer to the command
+	output, err := newSession(&table).run(input)
+	if err == ErrExit {
+		return ""
+	}
+	return formatResult(output, err)
+}
+
+func (s *session) run(input string) (string, error) {
+	// Find the command for the input and run it.
+	// The output is what the command did and the error is anything that went wrong
+	tokens, err := tokenize(input)
+	if err != nil {
+		return "", err
+	}
+	if len(tokens) == 0 {
+		return "", nil
+	}
+	command := tokens[0].Text
+	// The tokens the arguments of the command came from, so errors about an argument can point at it
+	arg_tokens := tokens[1:]
+	args := make([]string, len(tokens)-1)
+	for n, t := range tokens[1:] {
+		args[n] = t.Text
+	}
+
+	if command == "exit" {
+		return "", ErrExit
+	}
+
+	// An alias is replaced by the command it stands for, followed by the rest of the line
+	if alias, ok := s.aliases[command]; ok {
+		alias_tokens, err := tokenize(alias)
+		if err != nil || len(alias_tokens) == 0 {
+			return "", fmt.Errorf("The alias %s can't be run: %s", command, quoteArg(alias))
+		}
+		command = alias_tokens[0].Text
+		expanded := make([]string, 0, len(alias_tokens)-1+len(args))
+		for _, t := range alias_tokens[1:] {
+			expanded = append(expanded, t.Text)
+		}
+		args = append(expanded, args...)
+	}
+
+	// A line that is only a dice expression is rolled with r
+	if _, ok := commands[command]; !ok && isExpression(append([]string{command}, args...)) {
+		command, args = "r", append([]string{command}, args...)
+		arg_tokens = tokens
+	}
+	if _, ok := commands[command]; !ok {
+		return "", fmt.Errorf("%s is not a valid command. Maybe try help for a list of valid commands.\n%s", command, pointAt(input, tokens[0].Pos, tokens[0].End-tokens[0].Pos))
+	}
+
+	args, force, dry_run := splitOptions(args)
+	if dry_run {
+		output, err := s.dryRun(command, args)
+		return output, pointAtArgs(err, input, arg_tokens)
+	}
+
+	// Show what a destructive command would do and make sure the user wants to do it
+	if isDestructive(command, args) && s.confirm != nil && s.confirm_destructive && !force {
+		_, changes, _ := s.preview(command, args)
+		if len(changes) > 0 {
+			question := fmt.Sprintf("%s\nThis will change the dice above. Are you sure?", describeChanges(changes, s.table.Name))
+			if !s.confirm(question) {
+				return "Cancelled. Nothing was changed.", nil
+			}
+		}
+	}
+
+	// Keep the tables as they were and seed the dice so the log can show what changed and how to roll it again
+	before := s.copyTables()
+	active := s.table
+	seed := dice.Reseed()
+	output, err := commands[command](s, args)
+	var changes []tableChange
+	if !compound[command] {
+		changes = diffAll(before.tables, s.tables)
+	}
+	s.logEvents(active, command, args, seed, changes, output, err)
+	return output, pointAtArgs(err, input, arg_tokens)
+}
+
+func splitOptions(args []string) ([]string, bool, bool) {
+	// Take the options every command accepts out of the arguments.
+	// -y or --force skips confirmation and --dry-run shows what would change without changing anything.
+	// Arguments after -- are never treated as options
+	var rest []string
+	force := false
+	dry_run := false
+	for n, arg := range args {
+		switch arg {
+		case "-y", "--force":
+			force = true
+		case "--dry-run":
+			dry_run = true
+		case "--":
+			return append(rest, args[n+1:]...), force, dry_run
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest, force, dry_run
+}
+
+func (s *session) preview(command string, args []string) (string, []tableChange, error) {
+	// Run a command against a copy of the tables and return what it would change
+	copied := s.copyTables()
+	copied.previewing = true
+	output, err := commands[command](copied, args)
+	return output, diffAll(s.tables, copied.tables), err
+}
+
+func (s *session) dryRun(command string, args []string) (string, error) {
+	// Show what a command would do without changing the table
+	output, changes, err := s.preview(command, args)
+	return_str := "Dry run, nothing was changed.\n"
+	if output != "" {
+		return_str += strings.TrimRight(output, "\n") + "\n"
+	}
+	if len(changes) == 0 {
+		return_str += "The table would not change.\n"
 	} else {
-		return fmt.Sprintf("%s is not a valid command. Maybe try help for a list of valid commands.", input)
+		return_str += "Would change:\n" + describeChanges(changes, s.table.Name) + "\n"
+	}
+	return return_str, err
+}
+
+func formatResult(output string, err error) string {
+	// Join the output of a command and its error into the answer shown to the user
+	if err == nil {
+		return output
+	}
+	if output == "" {
+		return err.Error()
 	}
+	return strings.TrimRight(output, "\n") + "\n" + err.Error()
+}
 
+func joinErrors(errs []error) error {
+	// Combine the errors from each argument of a command into one error
+	if len(errs) == 0 {
+		return nil
+	}
+	return errorList(errs)
 }
 
-func help(table dice.Table, args []string) string {
+func help(s *session, args []string) (string, error) {
 	return `Commands:
 	help -	Display this prompt
 	roll - Roll the dice in any number of pools or roll all dice in the table
 		format: roll [pool/table] {if pool} [pool names]
-		examples: roll pool strength, roll table
+		examples: roll pool strength, roll table, roll pool strength --sorted
+	reroll - reroll dice in a pool picked by position, by face, or by comparing their face to a number.
+		Dice picked by face are rerolled once, or with --recursive until they stop matching. Positions start at 0
+		format: reroll pool [pool name] die [positions]/[faces or comparison] {optional} [--once/--recursive]
+		examples: reroll pool strength die 0,2, reroll pool strength 1, reroll pool strength <3 --recursive
+	r - Roll a dice expression without adding dice to the table. A line with only an expression does the same
+		format: r [dice expression]
+		examples: r 1d20+4, r 4d6kh3, 2d6 + 1d8 - 1
+	capture - Add the dice kept by the last expression rolled to the table as a pool
+		format: capture [pool name]
+		examples: capture damage
+	history - list the previous rolls of a pool
+		format: history pool [pool name] {optional} [number of rolls]
+		examples: history pool strength, history pool strength 5
+	stats - compare the rolls of each pool to what fair dice would roll
+		format: stats {optional} pool [pool names...]
+		examples: stats, stats pool strength
 	add - Add a die to a pool or a new pool to the table
 		format: add [die/pool] {if die} [pool names...] {if pool} [pool names:XdY...]
 		examples: add die strength agility, add pool power:4d6
+	rename - give a pool a new name
+		format: rename pool [pool name] [new name]
+		examples: rename pool power might
+	describe - set the description shown when a pool is viewed. An empty description removes it
+		format: describe pool [pool name] {optional} [description]
+		examples: describe pool strength "Rolled for melee attacks", describe pool strength ""
+	tag - add tags to a pool, show its tags, or list every tag on the table
+		format: tag {optional} pool [pool name] {optional} [tags...]
+		examples: tag pool strength combat, tag pool strength, tag
+	untag - remove tags from a pool
+		format: untag pool [pool name] [tags...]
+		examples: untag pool strength combat
 	subtract - Subtract a dice from any number of pools, or pools from the table
 		format: subtract [die/pool] {if die} [pool names:number of dice] {if pool} [pool names]
 		examples: subtract die strngth:3 agility:1, subtract pool strength agility
 	view - prints a discription of the pool or the whole table
 		format: view [pool/table] {if pool} [pool names]
-		examples: view pool strength, view table
+		examples: view pool strength, view table, view pool strength --grouped
+	sort - put the dice in pools in order of their faces, lowest first unless desc is given
+		format: sort pool [pool names...] {optional} [asc/desc]
+		examples: sort pool strength, sort pool strength desc
 	clear - removes all dice from a pool or all pools from the table
 		format - clear [pool/table] {if pool} [pool names]
 		examples: clear pool strength, clear table
-	set - set a die to a number, all dice in a pool to the same number, or all dice on the table to the same number
-		format: set die [pool name] [die position] [set to]/pool [pool name] [set to]/table [set to]`
+	set - set a die to a number, all dice in a pool to the same number or each to its own, or all dice on the table to the same number
+		format: set die [pool name] [die position] [set to]/pool [pool name] [set to or faces,...] {optional} [--resize]/table [set to]
+		examples: set die strength 0 6, set pool strength 3,5,6,1, set pool strength 6,6 --resize, set table 1
+	counter - keep numbers like hit points next to the dice, or clocks that fill up segment by segment.
+		Counters stop at their min and max. An amount can be pool:[pool name] to use the pool's total
+		format: counter new [name] [value] {optional} [--min number] [--max number]/clock [name] [segments]/
+			inc [name] {optional} [amount]/dec [name] {optional} [amount]/set [name] [value]/delete [name]/list
+		examples: counter new hp 30 --min 0 --max 30, counter dec hp pool:damage, counter clock heat 6, counter inc heat
+	init - roll initiative and keep the turn order of a fight. Ties go to the higher modifier, then to a roll off
+		format: init roll [names:modifiers...]/add [names:modifiers...]/remove [names...]/next/prev/delay {optional} [combatant]/end/view
+		examples: init roll fighter:+3 wizard:-1 goblin:2, init add troll:1, init end
+	next - move to the next combatant's turn, starting a new round after the last one
+	prev - move back to the previous combatant's turn
+	delay - move the combatant whose turn it is to just after another combatant, or after the next one
+		format: delay {optional} [combatant]
+		examples: delay, delay goblin
+	source - run the commands in a file one line at a time
+		format: source [file] {optional} [--continue]
+		examples: source setup.dice, source scenario.dice --continue
+	config - view or change the settings of the prompt, save them as the defaults, or change the config file
+		format: config {optional} [setting] {optional} [value]/save/file {optional} [key] {optional} [value]
+		examples: config, config confirm off, config format json, config prompt "{table} round {round}> ", config save, config file log.dir ~/dice
+	alias - make a name stand for a command, or list the aliases. The rest of the line is added to the command
+		format: alias {optional} [name] {optional} [command...]
+		examples: alias, alias atk roll pool attack, alias d20 1d20
+	unalias - remove aliases
+		format: unalias [names...]
+		examples: unalias atk
+	replay - rebuild the table from a log file. Without a file the table's own log is used
+		format: replay {optional} [log file]
+		examples: replay, replay ~/dice-logs/MyTable.jsonl
+	table - make, switch between, list, copy and delete the tables in this session, and save and load them between sessions
+		format: table new [name]/use [name]/list/copy [table name] [new table name]/delete [name]/save {optional} [name]/load [name]/saved
+		examples: table new dungeon, table use dungeon, table copy dungeon backup, table save, table load campaign
+	copy - copy a pool to another table, or to a new name on this table
+		format: copy pool [pool name] [table name] {optional} [new pool name]
+		examples: copy pool strength dungeon, copy pool strength dungeon might
+	move - move a pool to another table
+		format: move pool [pool name] [table name] {optional} [new pool name]
+		examples: move pool loot town
+	exit - leave the prompt
+
+Options for every command:
+	--dry-run - show what the command would change without changing anything
+	-y, --force - don't ask before clearing or removing pools
+	-- - treat everything after it as arguments even if they look like options
+
+Options for roll and view:
+	--list - show the faces in the order of the dice
+	--sorted - show the faces highest first without moving the dice
+	--grouped - show each face once with how many dice show it, like 6×3, 5×1, 2×2
+	--auto - group pools of more than 10 dice and list the rest
+
+Arguments are separated by any amount of whitespace. Wrap names containing spaces in quotes
+or escape the spaces with a backslash: add pool "Fire Bolt:8d6", roll pool Fire\ Bolt
+Anything after a # at the start of an argument is a comment.
+Wherever pool names are listed tag:[tag] picks every pool with the tag: roll tag:combat, view pool tag:magic`, nil
 }
 
-func roll(table dice.Table, args []string) string {
+func roll(s *session, args []string) (string, error) {
+	table := s.table
+	args, display := s.takeDisplay(args)
 	return_str := "Your Rolls:\n"
 	var str string
+	var errs []error
 
 	// Make sure that the user provides arguments
 	if len(args) < 1 {
-		return "Use roll pool [pool name] or roll table."
+		return "", errors.New("Use roll pool [pool name] or roll table.")
 	}
 
+	// roll tag:[tag] is short for roll pool tag:[tag]
+	if strings.HasPrefix(args[0], "tag:") {
+		args = append([]string{"pool"}, args...)
+	}
+
+	rolled := report.NewRoll(dice.CurrentSeed(), table.Name)
 	if args[0] == "pool" {
 		// Pool argument rolls a list of pools in the table
 
 		// Make sure that the pool name is provided with the pool argument
 		if len(args[1:]) < 1 {
-			return "Not the right ammount of arguments for roll pool [pool name]."
+			return "", errors.New("Not the right ammount of arguments for roll pool [pool name].")
 		}
 
 		// Roll the dice for each pool name provided
-		for _, name := range args[1:] {
+		var names []string
+		names, errs = s.selectPools(args[1:])
+		for _, name := range names {
 			if pool, ok := table.Pools[name]; ok {
 				pool.Roll()
-				str = fmt.Sprintf("Pool %s: %d Total: %d\n", name, pool.List(), pool.Total())
+				rolled.AddPool(name, pool)
+				str = fmt.Sprintf("Pool %s: %s Total: %d\n", name, pool.Faces(display), pool.Total())
+				return_str = return_str + str
 			} else {
-				str = fmt.Sprintf("Pool %s does not exist.\n", name)
+				errs = append(errs, argErrorf(name, "Pool %s does not exist.", name))
 			}
-			return_str = return_str + str
 		}
+		if return_str == "Your Rolls:\n" {
+			return "", joinErrors(errs)
+		}
+		return s.formatRoll(return_str, rolled), joinErrors(errs)
 	} else if args[0] == "table" {
 		// Roll each pool in the table if the table argument is provided
 
 		table.Roll()
 		for name, pool := range table.Pools {
-			str = fmt.Sprintf("Pool %s: %d Total: %d\n", name, pool.List(), pool.Total())
+			str = fmt.Sprintf("Pool %s: %s Total: %d\n", name, pool.Faces(display), pool.Total())
 			return_str = return_str + str
 		}
+		names := make([]string, 0, len(table.Pools))
+		for name := range table.Pools {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			rolled.AddPool(name, table.Pools[name])
+		}
+		return_str = s.formatRoll(return_str, rolled)
+	} else if isExpression(args) {
+		return rollExpression(s, args)
 	} else {
-		return_str = "roll command format is roll [table or pool] [pool names if pool]"
+		return "", errors.New("roll command format is roll [table or pool] [pool names if pool] or roll [dice expression]")
 	}
-	return return_str
+	return return_str, joinErrors(errs)
 }
 
-func add(table dice.Table, args []string) string {
+func add(s *session, args []string) (string, error) {
+	table := s.table
 	return_str := "Added:\n"
 	var str string
+	var errs []error
 
 	// Make sure at least two arguments are provided
 	if len(args) < 2 {
-		return "Not enough arguments provided. add [die/pool] [pool name/pool name:dice]"
+		return "", errors.New("Not enough arguments provided. add [die/pool] [pool name/pool name:dice]")
 	}
 
 	if args[0] == "die" {
 		// add a die to a pool
 
 		// loop through each name provided
-		for _, name := range args[1:] {
+		names, tag_errs := s.selectPools(args[1:])
+		errs = append(errs, tag_errs...)
+		for _, name := range names {
 
 			// Make sure each pool name provided exisits
 			if pool, ok := table.Pools[name]; ok {
 				pool.Add()
-				str = fmt.Sprintf("Successfully added die to pool %s. Now there are %dd%ds\n", name, pool.Sides, len(pool.Dice))
+				str = fmt.Sprintf("Successfully added die to pool %s. Now there are %dd%ds\n", name, len(pool.Dice), pool.Sides)
+				return_str = return_str + str
 			} else {
-				str = fmt.Sprintf("Pool %s does not exist.\n", name)
+				errs = append(errs, argErrorf(name, "Pool %s does not exist.", name))
 			}
-			return_str = return_str + str
 		}
 	} else if args[0] == "pool" {
 		// add a pool to the table
@@ -182,18 +515,19 @@ func add(table dice.Table, args []string) string {
 		for n, arg := range args[1:] {
 
 			// make sure that the name and XdY are separated by a colon
-			if !strings.Contains(arg, ":") {
-				str = fmt.Sprintf("Arg %d failed. Format is [name]:[XdY]", n)
-				return_str = return_str + str
+			name, dice_str, ok := splitNameArg(arg)
+			if !ok {
+				errs = append(errs, argErrorf(arg, "Arg %d failed. Format is [name]:[XdY]", n))
+				continue
+			}
+			if strings.HasPrefix(name, "tag:") {
+				errs = append(errs, argErrorf(arg, "Pool names cannot start with tag:, it is used to pick pools by their tags."))
 				continue
 			}
 
-			a := strings.Split(arg, ":")
-			name := a[0]
-			pool, err := dice.ParseDiceString(a[1])
+			pool, err := dice.ParseDiceString(dice_str)
 			if err != nil {
-				str = fmt.Sprintf("%s\n", err)
-				return_str = return_str + str
+				errs = append(errs, argErrorf(dice_str, "%v", err))
 				continue
 			}
 			table.Pools[name] = pool
@@ -201,247 +535,304 @@ func add(table dice.Table, args []string) string {
 			return_str = return_str + str
 		}
 	} else {
-		return_str = "add command format is add [die or pool] [pool name:XdY(if add pool)]"
+		return "", errors.New("add command format is add [die or pool] [pool name:XdY(if add pool)]")
+	}
+	if return_str == "Added:\n" {
+		return "", joinErrors(errs)
 	}
-	return return_str
+	return return_str, joinErrors(errs)
 }
 
-func subtract(table dice.Table, args []string) string {
-	return_str := "Subtracted:"
+func subtract(s *session, args []string) (string, error) {
+	table := s.table
+	return_str := "Subtracted:\n"
 	var str string
 	var err error
+	var errs []error
 
 	// Make sure at least two arguments are provided
 	if len(args) < 2 {
-		return "Not enough arguments provided. subtract [die/pool] [pool name:number of dice/pool name]"
+		return "", errors.New("Not enough arguments provided. subtract [die/pool] [pool name:number of dice/pool name]")
 	}
 
 	if args[0] == "die" {
-		for _, name := range args[1:] {
+		names, tag_errs := s.selectPools(args[1:])
+		errs = append(errs, tag_errs...)
+		for _, name := range names {
 			i := 1
 			plural := ""
 
 			// Check for a colon in argument. If so subtract multiple dice
-			if strings.Contains(name, ":") {
+			if pool_name, number, ok := splitNameArg(name); ok {
 				plural = "e"
-				s := strings.Split(name, ":")
-				name = s[0]
-				i, err = strconv.Atoi(s[1])
+				name = pool_name
+				i, err = strconv.Atoi(number)
 				if err != nil {
-					str = fmt.Sprintf("%s\n", err)
-					return_str = return_str + str
+					errs = append(errs, argErrorf(number, "%v", err))
 					continue
 				}
 			}
-			if pool, ok := table.Pools[name]; ok {
-				for c := 0; c < i; c++ {
-					err = pool.Subtract()
-					if err != nil {
-						str = fmt.Sprintf("%s\n", err)
-						return_str = return_str + str
-						break
-					}
+			pool, ok := table.Pools[name]
+			if !ok {
+				errs = append(errs, argErrorf(name, "Pool %s does not exist.", name))
+				continue
+			}
+			subtracted := 0
+			for c := 0; c < i; c++ {
+				err = pool.Subtract()
+				if err != nil {
+					errs = append(errs, err)
+					break
 				}
-				str = fmt.Sprintf("Successfully subtracted %d di%se from pool %s. Now there are %dd%ds\n", i, plural, name, pool.Sides, len(pool.Dice))
-			} else {
-				str = fmt.Sprintf("Pool %s does not exist.\n", name)
+				subtracted++
+			}
+			if subtracted > 0 {
+				str = fmt.Sprintf("Successfully subtracted %d di%se from pool %s. Now there are %dd%ds\n", subtracted, plural, name, len(pool.Dice), pool.Sides)
+				return_str = return_str + str
 			}
-			return_str = return_str + str
 		}
 	} else if args[0] == "pool" {
-		for _, arg := range args[1:] {
+		names, tag_errs := s.selectPools(args[1:])
+		errs = append(errs, tag_errs...)
+		for _, arg := range names {
 			err = table.Remove(arg)
 			if err != nil {
-				str = fmt.Sprintf("%s\n", err)
-				return_str = return_str + str
+				errs = append(errs, err)
 				continue
 			}
-			str = fmt.Sprintf("Successfully removed %s pool from table.", arg)
+			str = fmt.Sprintf("Successfully removed %s pool from table.\n", arg)
 			return_str = return_str + str
 		}
 	} else {
-		return_str = "subtract command format is add [die or pool] [pool name:number of dice if dice]"
+		return "", errors.New("subtract command format is add [die or pool] [pool name:number of dice if dice]")
 	}
-	return return_str
+	if return_str == "Subtracted:\n" {
+		return "", joinErrors(errs)
+	}
+	return return_str, joinErrors(errs)
 }
 
-func view(table dice.Table, args []string) string {
+func view(s *session, args []string) (string, error) {
 	// Print a descriptions of specific pools view pool [pool names...]
 	// or all pools. view table
+	table := s.table
+	args, display := s.takeDisplay(args)
 	return_str := "Pool Descriptions:\n"
 	var str string
+	var errs []error
 
 	// Make sure at least one argument is provided
 	if len(args) < 1 {
-		return "Not enough arguments provided. view [pool/table] [pool name]"
+		return "", errors.New("Not enough arguments provided. view [pool/table] [pool name]")
 	}
 
 	if args[0] == "pool" {
 		// Make sure at least one additonal argument is provided
 		if len(args[1:]) < 1 {
-			return "Not enough arguments provided. view [pool/table] [pool name]"
+			return "", errors.New("Not enough arguments provided. view [pool/table] [pool name]")
 		}
 
-		for _, name := range args[1:] {
+		var names []string
+		names, errs = s.selectPools(args[1:])
+		for _, name := range names {
 			if _, ok := table.Pools[name]; !ok {
-				str = fmt.Sprintf("%s is not the name of a pool on the table.\n", name)
-				return_str = return_str + str
+				errs = append(errs, argErrorf(name, "%s is not the name of a pool on the table.", name))
 				continue
 			}
-			str = fmt.Sprintf("%s: %s\n", name, table.Pools[name].Describe())
+			str = fmt.Sprintf("%s: %s\n", name, table.Pools[name].DescribeAs(display))
 			return_str = return_str + str
 		}
+		if return_str == "Pool Descriptions:\n" {
+			return "", joinErrors(errs)
+		}
+		return return_str, joinErrors(errs)
 	} else if args[0] == "table" {
 		for name, pool := range table.Pools {
-			str = fmt.Sprintf("%s: %s\n", name, pool.Describe())
+			str = fmt.Sprintf("%s: %s\n", name, pool.DescribeAs(display))
 			return_str = return_str + str
 		}
+		if len(table.Counters) > 0 {
+			return_str = return_str + listCounters(table)
+		}
+		if table.Initiative != nil {
+			return_str = return_str + "Initiative " + describeInitiative(table.Initiative)
+		}
 	} else {
-		return_str = "view command format is view [pool or table] if pool [pool name...]"
+		return "", errors.New("view command format is view [pool or table] if pool [pool name...]")
 	}
-	return return_str
+	return return_str, joinErrors(errs)
 }
 
-func clear(table dice.Table, args []string) string {
+func clear(s *session, args []string) (string, error) {
 	// Clears all dice from a number of pools. clear pool [pool names...]
 	// or clears all pools from the table. clear table
+	table := s.table
 	return_str := "Cleared:\n"
 	var str string
+	var errs []error
 
 	// Make sure at least one argument is provided
 	if len(args) < 1 {
-		return "Not enough arguments provided. clear [pool/table] [pool name]"
+		return "", errors.New("Not enough arguments provided. clear [pool/table] [pool name]")
 	}
 
 	if args[0] == "pool" {
 		// Make sure at least one additonal argument is provided
 		if len(args[1:]) < 1 {
-			return "Not enough arguments provided. clear [pool/table] [pool name]"
+			return "", errors.New("Not enough arguments provided. clear [pool/table] [pool name]")
 		}
 
-		for _, name := range args[1:] {
+		var names []string
+		names, errs = s.selectPools(args[1:])
+		for _, name := range names {
 			if _, ok := table.Pools[name]; !ok {
-				str = fmt.Sprintf("%s is not the name of a pool on the table.\n", name)
-				return_str = return_str + str
+				errs = append(errs, argErrorf(name, "%s is not the name of a pool on the table.", name))
 				continue
 			}
 			pool := table.Pools[name]
-			for x := 0; x < len(pool.Dice); x++ {
-				err := pool.Subtract()
-				if err != nil {
-					break
-				}
+			for len(pool.Dice) > 0 {
+				pool.Subtract()
 			}
 			str = fmt.Sprintf("Cleared pool %s\n", name)
 			return_str = return_str + str
 		}
+		if return_str == "Cleared:\n" {
+			return "", joinErrors(errs)
+		}
+		return return_str, joinErrors(errs)
 	} else if args[0] == "table" {
 		table.Clear()
 		str = "Cleared table\n"
 		return_str = return_str + str
+	} else {
+		return "", errors.New("clear command format is clear [pool or table] if pool [pool name...]")
 	}
-	return return_str
+	return return_str, joinErrors(errs)
 }
 
-func set(table dice.Table, args []string) string {
+func set(s *session, args []string) (string, error) {
 	// Set a specific die in a table to a number. set die [pool name] [die position] [set to]
-	// or set all dice in a pool to a number. set pool [pool names...] [set to]
+	// or set all dice in a pool to a number. set pool [pool name] [set to]
+	// or set each die in a pool to its own number. set pool [pool name] [faces,...] {optional} [--resize]
 	// or set all dice in the table to a specific number
-	return_str := "Set:"
+	table := s.table
+	return_str := "Set:\n"
 	var str string
 
+	// --resize adds or removes dice so a pool has one die for each face given
+	resize := false
+	var rest []string
+	for _, arg := range args {
+		if arg == "--resize" {
+			resize = true
+		} else {
+			rest = append(rest, arg)
+		}
+	}
+	args = rest
+
 	// Make sure at least one argument is provided
 	if len(args) < 1 {
-		return "Not enough arguments provided. set [die/pool/table] [pool names...] [die position] [set to]"
+		return "", errors.New("Not enough arguments provided. set [die/pool/table] [pool names...] [die position] [set to]")
 	}
 
 	if args[0] == "die" {
 		if len(args) != 4 {
-			return_str = "Not enough arguments to set a die. die [pool] [die] [set to].\n"
-			return return_str
+			return "", errors.New("Not enough arguments to set a die. die [pool] [die] [set to].")
 		}
 		pool_name := args[1]
 		if _, ok := table.Pools[pool_name]; !ok {
-			str = fmt.Sprintf("%s is not the name of a pool on the table.\n", pool_name)
-			return_str = return_str + str
-			return return_str
+			return "", argErrorf(pool_name, "%s is not the name of a pool on the table.", pool_name)
 		}
 		die, err := strconv.Atoi(args[2])
 		if err != nil {
-			str = fmt.Sprintf("%s", err)
-			return_str = return_str + str
-			return return_str
+			return "", argErrorf(args[2], "%v", err)
+		}
+		if die < 0 || die >= len(table.Pools[pool_name].Dice) {
+			return "", argErrorf(args[2], "There is no die %d in pool %s. Positions start at 0.", die, pool_name)
 		}
 		set_to, err := strconv.Atoi(args[3])
 		if err != nil {
-			str = fmt.Sprintf("%s", err)
-			return_str = return_str + str
-			return return_str
+			return "", argErrorf(args[3], "%v", err)
 		}
 		err = table.Pools[pool_name].Dice[die].Set(set_to)
 		if err != nil {
-			str = fmt.Sprintf("%s", err)
-			return_str = return_str + str
-			return return_str
+			return "", argErrorf(args[3], "%v", err)
 		}
 		str = fmt.Sprintf("Die %d in pool %s successfully set to %d.\n", die, pool_name, set_to)
 		return_str = return_str + str
 	} else if args[0] == "pool" {
 		if len(args) != 3 {
-			return_str = "Not enough arguments to set a pool. pool [pool] [set to].\n"
-			return return_str
+			return "", errors.New("Not enough arguments to set a pool. pool [pool] [set to or faces,...].")
 		}
 		pool_name := args[1]
 		if _, ok := table.Pools[pool_name]; !ok {
-			str = fmt.Sprintf("%s is not the name of a pool on the table.\n", pool_name)
-			return_str = return_str + str
-			return return_str
+			return "", argErrorf(pool_name, "%s is not the name of a pool on the table.", pool_name)
 		}
-		set_to, err := strconv.Atoi(args[3])
+		pool := table.Pools[pool_name]
+
+		// A list of faces sets each die to its own face
+		if strings.Contains(args[2], ",") || resize {
+			var faces []int
+			for _, face := range strings.Split(args[2], ",") {
+				n, err := strconv.Atoi(strings.TrimSpace(face))
+				if err != nil {
+					return "", argErrorf(face, "%s is not a face to set a die to.", quoteArg(face))
+				}
+				faces = append(faces, n)
+			}
+			err := pool.SetFaces(faces, resize)
+			if err != nil {
+				if !resize && len(faces) != len(pool.Dice) {
+					return "", fmt.Errorf("%s. Use --resize to change the size of the pool.", err)
+				}
+				return "", err
+			}
+			str = fmt.Sprintf("Successfully set pool %s to %d. Now there are %dd%ds\n", pool_name, pool.List(), len(pool.Dice), pool.Sides)
+			return return_str + str, nil
+		}
+
+		set_to, err := strconv.Atoi(args[2])
 		if err != nil {
-			str = fmt.Sprintf("%s", err)
-			return_str = return_str + str
-			return return_str
+			return "", argErrorf(args[2], "%v", err)
 		}
-		pool := table.Pools[pool_name]
 		for _, die := range pool.Dice {
 			err = die.Set(set_to)
 			if err != nil {
-				str = fmt.Sprintf("%s", err)
-				return_str = return_str + str
-				return return_str
+				return "", err
 			}
 		}
-		str = fmt.Sprintf("Successfully set all dice in %s pool to %d", pool_name, set_to)
+		str = fmt.Sprintf("Successfully set all dice in %s pool to %d\n", pool_name, set_to)
 		return_str = return_str + str
 	} else if args[0] == "table" {
 		if len(args) != 2 {
-			return_str = "Not enough arguments to set a table. table [set to].\n"
-			return return_str
+			return "", errors.New("Not enough arguments to set a table. table [set to].")
 		}
-		set_to, err := strconv.Atoi(args[3])
+		set_to, err := strconv.Atoi(args[1])
 		if err != nil {
-			str = fmt.Sprintf("%s", err)
-			return_str = return_str + str
-			return return_str
+			return "", argErrorf(args[1], "%v", err)
+		}
+		// Check every die first so the table isn't left half set
+		for name, pool := range table.Pools {
+			if set_to < 1 || set_to > pool.Sides {
+				return "", fmt.Errorf("The dice in pool %s cannot be set to %d, they have %d sides.", name, set_to, pool.Sides)
+			}
 		}
 		for _, pool := range table.Pools {
 			for _, die := range pool.Dice {
 				err = die.Set(set_to)
 				if err != nil {
-					str = fmt.Sprintf("%s", err)
-					return_str = return_str + str
-					return return_str
+					return "", err
 				}
 			}
 		}
-		str = fmt.Sprintf("Successfully set all dice in on the table to %d", set_to)
+		str = fmt.Sprintf("Successfully set all dice in on the table to %d\n", set_to)
 		return_str = return_str + str
 	} else {
-		return_str = `set command format set [die, pool, or table]: 
+		return "", errors.New(`set command format set [die, pool, or table]: 
 		die [pool name] [die] [set_to]
-		pool [pool name] [set to]
-		table [set to]`
+		pool [pool name] [set to or faces,...] {optional} [--resize]
+		table [set to]`)
 	}
-	return return_str
+	return return_str, nil
 }
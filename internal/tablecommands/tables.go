@@ -0,0 +1,195 @@
+package tablecommands
+
+import (
+	"dicetable/pkg/dice"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+func tableCommand(s *session, args []string) (string, error) {
+	// Manage the tables in the session.
+	// table new [name], table use [name], table list, table copy [name] [new name], table delete [name]
+	// Tables are kept between sessions with table save {optional} [name], table load [name] and table saved
+	if len(args) < 1 {
+		return "", errors.New("Not enough arguments provided. table [new/use/list/copy/delete/save/load/saved] [table names]")
+	}
+
+	switch args[0] {
+	case "new":
+		if len(args) != 2 {
+			return "", errors.New("table new format is table new [name]")
+		}
+		name := args[1]
+		if _, ok := s.tables[name]; ok {
+			return "", argErrorf(name, "There is already a table named %s.", quoteArg(name))
+		}
+		s.tables[name] = &dice.Table{Pools: make(map[string]*dice.Pool), Name: name}
+		s.table = s.tables[name]
+		return fmt.Sprintf("Created table %s and switched to it.\n", quoteArg(name)), nil
+	case "use":
+		if len(args) != 2 {
+			return "", errors.New("table use format is table use [name]")
+		}
+		table, ok := s.tables[args[1]]
+		if !ok {
+			return "", argErrorf(args[1], "%s is not the name of a table. Use table list to see the tables.", quoteArg(args[1]))
+		}
+		s.table = table
+		return fmt.Sprintf("Switched to table %s.\n", quoteArg(args[1])), nil
+	case "list":
+		return_str := "Tables:\n"
+		for _, name := range s.tableNames() {
+			marker := " "
+			if s.tables[name] == s.table {
+				marker = "*"
+			}
+			return_str += fmt.Sprintf("%s %s: %d pools\n", marker, quoteArg(name), len(s.tables[name].Pools))
+		}
+		return return_str, nil
+	case "copy":
+		if len(args) != 3 {
+			return "", errors.New("table copy format is table copy [table name] [new table name]")
+		}
+		table, ok := s.tables[args[1]]
+		if !ok {
+			return "", argErrorf(args[1], "%s is not the name of a table.", quoteArg(args[1]))
+		}
+		if _, ok := s.tables[args[2]]; ok {
+			return "", argErrorf(args[2], "There is already a table named %s.", quoteArg(args[2]))
+		}
+		copied := table.Copy()
+		copied.Name = args[2]
+		s.tables[args[2]] = &copied
+		return fmt.Sprintf("Copied table %s to %s.\n", quoteArg(args[1]), quoteArg(args[2])), nil
+	case "delete":
+		if len(args) != 2 {
+			return "", errors.New("table delete format is table delete [name]")
+		}
+		table, ok := s.tables[args[1]]
+		if !ok {
+			return "", argErrorf(args[1], "%s is not the name of a table.", quoteArg(args[1]))
+		}
+		if len(s.tables) == 1 {
+			return "", errors.New("Cannot delete the only table. Use clear table to remove its pools.")
+		}
+		delete(s.tables, args[1])
+		return_str := fmt.Sprintf("Deleted table %s.\n", quoteArg(args[1]))
+
+		// If the active table was deleted switch to the first of the tables left
+		if table == s.table {
+			s.table = s.tables[s.tableNames()[0]]
+			return_str += fmt.Sprintf("Switched to table %s.\n", quoteArg(s.table.Name))
+		}
+		return return_str, nil
+	case "save", "load", "saved":
+		if s.store == nil {
+			return "", errors.New("There is nowhere to save tables to.")
+		}
+		return storeCommand(s, args)
+	}
+	return "", errors.New("table command format is table [new/use/list/copy/delete/save/load/saved] [table names]")
+}
+
+func storeCommand(s *session, args []string) (string, error) {
+	switch args[0] {
+	case "save":
+		if len(args) > 2 {
+			return "", errors.New("table save format is table save {optional} [name]")
+		}
+		table := s.table
+		if len(args) == 2 {
+			var ok bool
+			table, ok = s.tables[args[1]]
+			if !ok {
+				return "", argErrorf(args[1], "%s is not the name of a table.", quoteArg(args[1]))
+			}
+		}
+		if s.previewing {
+			return fmt.Sprintf("Would save table %s.\n", quoteArg(table.Name)), nil
+		}
+		err := s.store.Save(table)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Saved table %s.\n", quoteArg(table.Name)), nil
+	case "load":
+		if len(args) != 2 {
+			return "", errors.New("table load format is table load [name]")
+		}
+		if _, ok := s.tables[args[1]]; ok {
+			return "", argErrorf(args[1], "Table %s is already open. Use table use %s to switch to it.", quoteArg(args[1]), quoteArg(args[1]))
+		}
+		table, err := s.store.Load(args[1])
+		if err != nil {
+			return "", err
+		}
+		s.tables[table.Name] = &table
+		s.table = &table
+		return fmt.Sprintf("Loaded table %s and switched to it.\n", quoteArg(table.Name)), nil
+	}
+
+	files, err := s.store.List()
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "There are no saved tables.\n", nil
+	}
+	return_str := "Saved tables:\n"
+	for _, file := range files {
+		return_str += fmt.Sprintf("%s: %d pools, saved %s\n", quoteArg(file.Name), len(file.Pools), file.Saved.Format("2006-01-02 15:04"))
+	}
+	return return_str, nil
+}
+
+func (s *session) tableNames() []string {
+	names := make([]string, 0, len(s.tables))
+	for name := range s.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func copyPool(s *session, args []string) (string, error) {
+	// Copy a pool to another table. copy pool [pool name] [table name] {optional} [new pool name]
+	return transferPool(s, args, "copy")
+}
+
+func movePool(s *session, args []string) (string, error) {
+	// Move a pool to another table. move pool [pool name] [table name] {optional} [new pool name]
+	return transferPool(s, args, "move")
+}
+
+func transferPool(s *session, args []string, command string) (string, error) {
+	if len(args) < 3 || len(args) > 4 || args[0] != "pool" {
+		return "", fmt.Errorf("%s command format is %s pool [pool name] [table name] {optional} [new pool name]", command, command)
+	}
+	name := args[1]
+	pool, ok := s.table.Pools[name]
+	if !ok {
+		return "", argErrorf(name, "%s is not the name of a pool on the table.", name)
+	}
+	target, ok := s.tables[args[2]]
+	if !ok {
+		return "", argErrorf(args[2], "%s is not the name of a table.", quoteArg(args[2]))
+	}
+	new_name := name
+	if len(args) == 4 {
+		new_name = args[3]
+	}
+	if target == s.table && new_name == name {
+		return "", fmt.Errorf("Pool %s is already on table %s. Give the %s a new name.", name, quoteArg(target.Name), command)
+	}
+	if _, ok := target.Pools[new_name]; ok {
+		return "", argErrorf(new_name, "Table %s already has a pool named %s.", quoteArg(target.Name), new_name)
+	}
+
+	target.Pools[new_name] = pool.Copy()
+	if command == "move" {
+		delete(s.table.Pools, name)
+		return fmt.Sprintf("Moved pool %s to table %s as %s.\n", name, quoteArg(target.Name), new_name), nil
+	}
+	return fmt.Sprintf("Copied pool %s to table %s as %s.\n", name, quoteArg(target.Name), new_name), nil
+}
@@ -0,0 +1,37 @@
+package tablecommands_test
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"reflect"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	// Complete should suggest commands, then keywords, then pool names
+	table, _ := dice.ParseTableString([]string{"4d6", "2d6", "1d8"}, []string{"strength", "stamina", "Fire Bolt"})
+	table.Pools["strength"].Tag("combat")
+
+	cases := []struct {
+		line     string
+		start    int
+		expected []string
+	}{
+		{"ro", 0, []string{"roll"}},
+		{"roll ", 5, []string{"pool", "table", "tag:combat"}},
+		{"add d", 4, []string{"die"}},
+		{"roll pool st", 10, []string{"stamina", "strength"}},
+		{"view pool strength F", 19, []string{`"Fire Bolt"`}},
+		{`view pool "Fire B`, 10, []string{`"Fire Bolt"`}},
+		{"add pool ", 9, nil},
+		{"set pool strength ", 18, nil},
+		{"roll pool tag:", 10, []string{"tag:combat"}},
+		{"untag pool strength ", 20, []string{"combat"}},
+	}
+	for _, c := range cases {
+		start, matches := tablecommands.Complete(table, c.line)
+		if start != c.start || !reflect.DeepEqual(matches, c.expected) {
+			t.Errorf("Complete(%q) returned %d %q, expected %d %q", c.line, start, matches, c.start, c.expected)
+		}
+	}
+}
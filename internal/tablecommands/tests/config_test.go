@@ -0,0 +1,85 @@
+package tablecommands_test
+
+import (
+	"dicetable/internal/config"
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAliases(t *testing.T) {
+	// An alias should run its command with the rest of the line added, and can't hide a command
+	tablecommands.SetLogConfig(tablecommands.LogConfig{Disabled: true})
+	table, _ := dice.ParseTableString([]string{"4d6", "2d6"}, []string{"strength", "agility"})
+	var out strings.Builder
+	script := "alias rp roll pool\nrp agility\nalias d20 1d20+2\nd20\nalias roll view table\nunalias rp\nrp strength\n"
+	err := tablecommands.RunScript("alias.dice", strings.NewReader(script), &table, &out, true)
+
+	script_err, ok := err.(*tablecommands.ScriptError)
+	if !ok || len(script_err.Failures) != 2 || script_err.Failures[0].Line != 5 || script_err.Failures[1].Line != 7 {
+		t.Fatalf("Only aliasing roll and running the removed alias should have failed, instead got %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "Pool agility:") {
+		t.Errorf("rp agility should have rolled agility:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "1d20+2: [") {
+		t.Errorf("An alias should be able to stand for a dice expression:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "roll is already a command.") {
+		t.Errorf("Aliases should not hide commands:\n%s", out.String())
+	}
+}
+
+func TestConfigFile(t *testing.T) {
+	// Settings and aliases should come from the config file and config save should write them back
+	path := filepath.Join(t.TempDir(), "config")
+	os.WriteFile(path, []byte("display = sorted\nalias.st = view pool strength\nlog.dir = /tmp/dice\n"), 0600)
+	file, _ := config.Load(path)
+	if err := tablecommands.SetConfig(file); err != nil {
+		t.Fatal(err)
+	}
+	defer tablecommands.SetConfig(nil)
+
+	table, _ := dice.ParseTableString([]string{"3d6"}, []string{"strength"})
+	table.Pools["strength"].SetFaces([]int{1, 6, 3}, false)
+	if output := tablecommands.ParseCommand("st", table); !strings.Contains(output, "facing 6, 3, and 1") {
+		t.Errorf("The alias and display setting from the file should be used:\n%s", output)
+	}
+
+	// Dry runs shouldn't touch the file
+	before, _ := os.ReadFile(path)
+	for _, command := range []string{"config save --dry-run", "config file display grouped --dry-run"} {
+		if output := tablecommands.ParseCommand(command, table); !strings.Contains(output, "Would ") {
+			t.Errorf("%s should say what it would write:\n%s", command, output)
+		}
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Errorf("The dry runs should have left the config file as it was:\n%s", after)
+	}
+
+	var out strings.Builder
+	script := "config confirm off\nalias rs roll pool strength\nconfig save\nconfig file log.dir \"\"\n"
+	err := tablecommands.RunScript("config.dice", strings.NewReader(script), &table, &out, false)
+	if err != nil {
+		t.Fatalf("The script should run, instead got %v\n%s", err, out.String())
+	}
+	saved, _ := config.Load(path)
+	if value, _ := saved.Get("confirm"); value != "off" {
+		t.Errorf("config save should have written confirm = off, instead got %q", value)
+	}
+	if value, _ := saved.Get("alias.rs"); value != "roll pool strength" {
+		t.Errorf("config save should have written the new alias, instead got %q", value)
+	}
+	if _, ok := saved.Get("log.dir"); ok {
+		t.Errorf("config file log.dir \"\" should have removed log.dir")
+	}
+
+	bad, _ := config.Load(path)
+	bad.Set("format", "xml")
+	if err := tablecommands.SetConfig(bad); err == nil || !strings.Contains(err.Error(), "format") {
+		t.Errorf("A config file with a bad format should be refused, instead got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package tablecommands_test
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const counters_script = `counter new hp 30 --min 0 --max 30
+set pool damage 3,4,5
+counter dec hp pool:damage
+counter inc hp 50
+counter clock heat 4
+counter inc heat 3
+counter inc heat
+view table
+counter dec missing
+`
+
+func TestCounters(t *testing.T) {
+	// Counters should stop at their bounds, take amounts from pools and be shown with the table
+	table, _ := dice.ParseTableString([]string{"3d6"}, []string{"damage"})
+	var out strings.Builder
+	err := tablecommands.RunScript("counters.dice", strings.NewReader(counters_script), &table, &out, true)
+
+	script_err, ok := err.(*tablecommands.ScriptError)
+	if !ok || len(script_err.Failures) != 1 || script_err.Failures[0].Line != 9 {
+		t.Fatalf("Only the missing counter should have failed, instead got %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "Counter hp: 30 -> 18 (min 0, max 30) (pool damage total 12)") {
+		t.Errorf("counter dec should take the pool's total off hp:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Stopped at 30 instead of 68.") {
+		t.Errorf("counter inc should stop at the max and say so:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Clock heat is full.") {
+		t.Errorf("Filling the last segment of a clock should say it is full:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Counters:\nheat: [####] 4/4\nhp: 30 (min 0, max 30)\n") {
+		t.Errorf("view table should list the counters:\n%s", out.String())
+	}
+}
+
+func TestCountersReplay(t *testing.T) {
+	// Counters should be logged and come back when the log is replayed
+	tablecommands.SetLogConfig(tablecommands.LogConfig{Dir: t.TempDir()})
+	defer stopLogging()
+
+	table, _ := dice.ParseTableString([]string{}, []string{})
+	table.Name = "CounterTest"
+	log_dir, err := tablecommands.StartLog(table.Name)
+	if err != nil {
+		t.Fatalf("StartLog returned an error: %v", err)
+	}
+	for _, command := range []string{"counter new hp 30 --max 30", "counter dec hp 7", "counter clock heat 6", "counter inc heat", "counter new stress 0", "counter delete stress -y"} {
+		tablecommands.ParseCommand(command, table)
+	}
+
+	file, err := os.Open(filepath.Join(log_dir, "CounterTest.jsonl"))
+	if err != nil {
+		t.Fatalf("The log file was not created: %v", err)
+	}
+	defer file.Close()
+	replayed, _ := dice.ParseTableString([]string{}, []string{})
+	if _, err := tablecommands.Replay(file, &replayed); err != nil {
+		t.Fatalf("Replay returned an error: %v", err)
+	}
+	if len(replayed.Counters) != 2 || !replayed.Counters["hp"].Equal(table.Counters["hp"]) || !replayed.Counters["heat"].Equal(table.Counters["heat"]) {
+		t.Errorf("The replayed counters should match the original, instead they are %v", replayed.Counters)
+	}
+}
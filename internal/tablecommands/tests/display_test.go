@@ -0,0 +1,73 @@
+package tablecommands_test
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSortAndDisplay(t *testing.T) {
+	// sort should move the dice and the display options should only change how they are shown
+	table, _ := dice.ParseTableString([]string{"6d6"}, []string{"horde"})
+	table.Pools["horde"].SetFaces([]int{2, 6, 5, 6, 2, 6}, false)
+
+	output := tablecommands.ParseCommand("view pool horde --grouped", table)
+	if !strings.Contains(output, "The dice are facing 6×3, 5×1, 2×2.") {
+		t.Errorf("view --grouped should group the faces:\n%s", output)
+	}
+	output = tablecommands.ParseCommand("view pool horde --sorted", table)
+	if !strings.Contains(output, "The dice are facing 6, 6, 6, 5, 2, and 2.") {
+		t.Errorf("view --sorted should sort the faces:\n%s", output)
+	}
+	if !reflect.DeepEqual(table.Pools["horde"].List(), []int{2, 6, 5, 6, 2, 6}) {
+		t.Errorf("view should not move the dice, instead the pool is %d", table.Pools["horde"].List())
+	}
+
+	output = tablecommands.ParseCommand("sort pool horde desc", table)
+	if !strings.Contains(output, "Pool horde: [6 6 6 5 2 2] Total: 27") {
+		t.Errorf("sort desc should put the dice highest first:\n%s", output)
+	}
+	tablecommands.ParseCommand("sort pool horde", table)
+	if !reflect.DeepEqual(table.Pools["horde"].List(), []int{2, 2, 5, 6, 6, 6}) {
+		t.Errorf("sort should put the dice lowest first, instead the pool is %d", table.Pools["horde"].List())
+	}
+
+	output = tablecommands.ParseCommand("roll pool horde --grouped", table)
+	if !strings.Contains(output, "×") {
+		t.Errorf("roll --grouped should group the faces:\n%s", output)
+	}
+}
+
+func TestDisplaySetting(t *testing.T) {
+	// config display should change how roll shows the dice until an option overrides it
+	table, _ := dice.ParseTableString([]string{"3d1"}, []string{"ones"})
+	var out strings.Builder
+	tablecommands.RunScript("display.dice", strings.NewReader("config display grouped\nroll pool ones\nroll pool ones --list\nconfig display fancy\n"), &table, &out, true)
+	if !strings.Contains(out.String(), "Pool ones: 1×3 Total: 3") || !strings.Contains(out.String(), "Pool ones: [1 1 1] Total: 3") {
+		t.Errorf("The display setting should group the roll and --list should override it:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "fancy is not a way to show dice") {
+		t.Errorf("config display should refuse unknown ways to show dice:\n%s", out.String())
+	}
+}
+
+func TestDisplayAuto(t *testing.T) {
+	// Big pools should only be grouped when auto is asked for, and only once they have more than 10 dice
+	table, _ := dice.ParseTableString([]string{"11d1", "10d1"}, []string{"big", "small"})
+	output := tablecommands.ParseCommand("view pool big", table)
+	if !strings.Contains(output, "The dice are facing 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, and 1.") {
+		t.Errorf("view should list a big pool by default:\n%s", output)
+	}
+	output = tablecommands.ParseCommand("view pool big small --auto", table)
+	if !strings.Contains(output, "big: A pool of 11 d1s. The dice are facing 1×11.") || !strings.Contains(output, "small: A pool of 10 d1s. The dice are facing 1, 1,") {
+		t.Errorf("view --auto should group only the pool over 10 dice:\n%s", output)
+	}
+
+	var out strings.Builder
+	tablecommands.RunScript("auto.dice", strings.NewReader("config display auto\nroll pool big small\n"), &table, &out, true)
+	if !strings.Contains(out.String(), "Pool big: 1×11 Total: 11") || !strings.Contains(out.String(), "Pool small: [1 1 1 1 1 1 1 1 1 1] Total: 10") {
+		t.Errorf("config display auto should group only the pool over 10 dice:\n%s", out.String())
+	}
+}
@@ -0,0 +1,142 @@
+package tablecommands_test
+
+import (
+	"bufio"
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEventLogReplay(t *testing.T) {
+	// Each command should be logged as a line of JSON and replaying the log should rebuild the table
+	tablecommands.SetLogConfig(tablecommands.LogConfig{Dir: t.TempDir()})
+	defer stopLogging()
+
+	table, _ := dice.ParseTableString([]string{}, []string{})
+	table.Name = "LogTest"
+	log_dir, err := tablecommands.StartLog(table.Name)
+	if err != nil {
+		t.Fatalf("StartLog returned an error: %v", err)
+	}
+
+	for _, command := range []string{"add pool strength:4d6 agility:2d6", "roll table", "add die strength", "subtract pool agility", "roll pool missing"} {
+		tablecommands.ParseCommand(command, table)
+	}
+
+	file, err := os.Open(filepath.Join(log_dir, "LogTest.jsonl"))
+	if err != nil {
+		t.Fatalf("The log file was not created: %v", err)
+	}
+	defer file.Close()
+
+	var events []tablecommands.Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event tablecommands.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Could not read a line of the log: %v", err)
+		}
+		events = append(events, event)
+	}
+	if len(events) != 5 {
+		t.Fatalf("There should be 5 events in the log, instead there are %d", len(events))
+	}
+	if events[0].Command != "add" || !reflect.DeepEqual(events[0].Args, []string{"pool", "strength:4d6", "agility:2d6"}) || len(events[0].Changes) != 2 {
+		t.Errorf("The first event should be the add command adding two pools, instead it was %+v", events[0])
+	}
+	if change := events[3].Changes[0]; change.Pool != "agility" || change.Before == nil || change.After != nil {
+		t.Errorf("The subtract event should show agility being removed, instead it was %+v", change)
+	}
+	if events[4].Error == "" {
+		t.Errorf("The failed roll should have its error logged")
+	}
+
+	// Replay the log into a new table and compare it to the original
+	file.Seek(0, 0)
+	replayed, _ := dice.ParseTableString([]string{}, []string{})
+	count, err := tablecommands.Replay(file, &replayed)
+	if err != nil || count != 5 {
+		t.Fatalf("Replay should have read 5 events without an error, instead read %d and returned %v", count, err)
+	}
+	if len(replayed.Pools) != 1 || !reflect.DeepEqual(replayed.Pools["strength"].List(), table.Pools["strength"].List()) {
+		t.Errorf("The replayed table should match the original, instead strength is facing %d", replayed.Pools["strength"].List())
+	}
+}
+
+func stopLogging() {
+	// Close the log a test opened so later tests don't write to it
+	tablecommands.SetLogConfig(tablecommands.LogConfig{Disabled: true})
+	tablecommands.StartLog("")
+}
+
+func TestDryRunNotLogged(t *testing.T) {
+	// Commands run by a dry run didn't happen, so they shouldn't be written to the log
+	dir := t.TempDir()
+	tablecommands.SetLogConfig(tablecommands.LogConfig{Dir: dir})
+	defer stopLogging()
+
+	table, _ := dice.ParseTableString([]string{"4d6"}, []string{"strength"})
+	table.Name = "DryRun"
+	if _, err := tablecommands.StartLog(table.Name); err != nil {
+		t.Fatalf("StartLog returned an error: %v", err)
+	}
+	script := filepath.Join(dir, "rolls.dice")
+	os.WriteFile(script, []byte("roll pool strength\nadd die strength\n"), 0600)
+
+	tablecommands.ParseCommand("roll table", table)
+	logged, _ := os.ReadFile(filepath.Join(dir, "DryRun.jsonl"))
+	for _, command := range []string{"source " + script + " --dry-run", "roll table --dry-run", "clear pool strength --dry-run"} {
+		tablecommands.ParseCommand(command, table)
+	}
+	after, _ := os.ReadFile(filepath.Join(dir, "DryRun.jsonl"))
+	if string(after) != string(logged) {
+		t.Errorf("The dry runs should have left the log as it was, instead it gained:\n%s", after[len(logged):])
+	}
+}
+
+func TestReplayOwnLog(t *testing.T) {
+	// replay without a file should preview the table's own log, both to confirm it and for a dry run
+	logger, err := tablecommands.NewLogger(tablecommands.LogConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewLogger returned an error: %v", err)
+	}
+	defer logger.Close()
+	table := dice.Table{Pools: make(map[string]*dice.Pool), Name: "Replayed"}
+	var out strings.Builder
+	session := tablecommands.NewSession(&table, strings.NewReader("add pool strength:4d6\n"), &out, logger, nil)
+	if err := session.Run(); err != nil {
+		t.Fatalf("Run returned an error: %v", err)
+	}
+	// A pool the log doesn't know about, which replaying the log would remove
+	extra, _ := dice.ParseTableString([]string{"2d6"}, []string{"agility"})
+	table.Pools["agility"] = extra.Pools["agility"]
+
+	out.Reset()
+	session = tablecommands.NewSession(&table, strings.NewReader("replay\nn\n"), &out, logger, nil)
+	if err := session.Run(); err != nil {
+		t.Fatalf("Run returned an error: %v", err)
+	}
+	if !strings.Contains(out.String(), "- pool agility") || !strings.Contains(out.String(), "Are you sure?") {
+		t.Errorf("replay should ask before removing agility:\n%s", out.String())
+	}
+	if table.Pools["agility"] == nil {
+		t.Errorf("Answering no should have kept agility")
+	}
+
+	out.Reset()
+	session = tablecommands.NewSession(&table, strings.NewReader("replay --dry-run\n"), &out, logger, nil)
+	if err := session.Run(); err != nil {
+		t.Fatalf("Run returned an error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Would change:") || !strings.Contains(out.String(), "- pool agility") {
+		t.Errorf("replay --dry-run should show that agility would be removed:\n%s", out.String())
+	}
+	if table.Pools["agility"] == nil {
+		t.Errorf("The dry run should have kept agility")
+	}
+}
@@ -0,0 +1,77 @@
+package tablecommands_test
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpressionRolls(t *testing.T) {
+	// Expressions should roll without changing the table until they are captured
+	table, _ := dice.ParseTableString([]string{}, []string{})
+	var out strings.Builder
+	err := tablecommands.RunScript("rolls.dice", strings.NewReader("r 1d20 + 4\n3d6\nroll 1d4\n2d8 + 1d6\ncapture damage\n"), &table, &out, false)
+	if err != nil {
+		t.Fatalf("RunScript returned an error: %v\n%s", err, out.String())
+	}
+
+	for _, expected := range []string{"1d20+4: [", "3d6: [", "1d4: [", "2d8+1d6: ["} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("The output should show the breakdown %s...:\n%s", expected, out.String())
+		}
+	}
+	if len(table.Pools) != 2 || len(table.Pools["damage-1"].Dice) != 2 || table.Pools["damage-2"].Sides != 6 {
+		t.Errorf("capture should have added the 2d8 and 1d6 as damage-1 and damage-2, instead the table has %d pools", len(table.Pools))
+	}
+
+	output := tablecommands.ParseCommand("capture again", table)
+	if !strings.Contains(output, "Nothing has been rolled") {
+		t.Errorf("capture without a roll should fail, instead got %s", output)
+	}
+	output = tablecommands.ParseCommand("2d", table)
+	if !strings.Contains(output, "not a valid command") {
+		t.Errorf("A line that isn't a command or an expression should fail, instead got %s", output)
+	}
+}
+
+func TestCaptureKeptDice(t *testing.T) {
+	// capture should add only the dice an expression kept, with the roll in the history so replay brings it back
+	tablecommands.SetLogConfig(tablecommands.LogConfig{Dir: t.TempDir()})
+	defer stopLogging()
+
+	table, _ := dice.ParseTableString([]string{}, []string{})
+	table.Name = "CaptureTest"
+	log_dir, err := tablecommands.StartLog(table.Name)
+	if err != nil {
+		t.Fatalf("StartLog returned an error: %v", err)
+	}
+	var out strings.Builder
+	if err := tablecommands.RunScript("stat.dice", strings.NewReader("r 4d6kh3\ncapture stat\n"), &table, &out, false); err != nil {
+		t.Fatalf("RunScript returned an error: %v\n%s", err, out.String())
+	}
+
+	pool := table.Pools["stat"]
+	if pool == nil || len(pool.Dice) != 3 {
+		t.Fatalf("capture should have added the 3 kept dice as stat, instead the table has %v", table.Pools)
+	}
+	if len(pool.History) != 1 || !reflect.DeepEqual(pool.History[0], pool.List()) {
+		t.Errorf("The captured roll should be the history of stat, instead it is %v", pool.History)
+	}
+
+	file, err := os.Open(filepath.Join(log_dir, "CaptureTest.jsonl"))
+	if err != nil {
+		t.Fatalf("The log file was not created: %v", err)
+	}
+	defer file.Close()
+	replayed, _ := dice.ParseTableString([]string{}, []string{})
+	if _, err := tablecommands.Replay(file, &replayed); err != nil {
+		t.Fatalf("Replay returned an error: %v", err)
+	}
+	if got := replayed.Pools["stat"]; got == nil || !reflect.DeepEqual(got.List(), pool.List()) || !reflect.DeepEqual(got.History, pool.History) {
+		t.Errorf("Replaying the log should bring back stat as it was captured, instead got %v", got)
+	}
+}
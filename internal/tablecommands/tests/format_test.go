@@ -0,0 +1,38 @@
+package tablecommands_test
+
+import (
+	"dicetable/internal/report"
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRollFormat(t *testing.T) {
+	// With config format json roll and r should print JSON with the pools they rolled
+	tablecommands.SetLogConfig(tablecommands.LogConfig{Disabled: true})
+	table, _ := dice.ParseTableString([]string{"4d6", "2d8"}, []string{"strength", "agility"})
+	var out strings.Builder
+	err := tablecommands.RunScript("format.dice", strings.NewReader("config format json\nroll table\n"), &table, &out, false)
+	if err != nil {
+		t.Fatalf("The script should run, instead got %v\n%s", err, out.String())
+	}
+	text := out.String()
+	start := strings.Index(text, "{")
+	var rolled report.Roll
+	if err := json.Unmarshal([]byte(text[start:]), &rolled); err != nil {
+		t.Fatalf("roll table should print JSON: %v\n%s", err, text)
+	}
+	if len(rolled.Pools) != 2 || rolled.Pools[0].Name != "agility" || rolled.Pools[1].Name != "strength" {
+		t.Errorf("The pools should be in order of their names, instead got %+v", rolled.Pools)
+	}
+	if rolled.Total != table.Pools["strength"].Total()+table.Pools["agility"].Total() {
+		t.Errorf("The total should be the total of every pool, instead got %d", rolled.Total)
+	}
+
+	output := tablecommands.ParseCommand("config format xml", table)
+	if !strings.Contains(output, "xml is not an output format.") {
+		t.Errorf("Unknown formats should be refused:\n%s", output)
+	}
+}
@@ -0,0 +1,72 @@
+package tablecommands_test
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	// history should list the rolls of a pool and stats should summarise them
+	table, _ := dice.ParseTableString([]string{"4d6", "1d20"}, []string{"strength", "luck"})
+	for n := 0; n < 5; n++ {
+		tablecommands.ParseCommand("roll pool strength", table)
+	}
+
+	output := tablecommands.ParseCommand("history pool strength", table)
+	if !strings.Contains(output, "History of pool strength:") || !strings.Contains(output, "\n5: ") {
+		t.Errorf("history should list all 5 rolls:\n%s", output)
+	}
+	output = tablecommands.ParseCommand("history pool strength 2", table)
+	if strings.Contains(output, "\n3: ") || !strings.Contains(output, "\n4: ") {
+		t.Errorf("history with a count should only list the last 2 rolls:\n%s", output)
+	}
+	if output := tablecommands.ParseCommand("history pool luck", table); !strings.Contains(output, "has not been rolled yet") {
+		t.Errorf("history of a pool that hasn't been rolled should say so:\n%s", output)
+	}
+
+	output = tablecommands.ParseCommand("stats", table)
+	if !strings.Contains(output, "strength (d6): 5 rolls") || !strings.Contains(output, "luck (d20): not rolled yet") {
+		t.Errorf("stats should have a line for each pool:\n%s", output)
+	}
+	output = tablecommands.ParseCommand("stats pool strength missing", table)
+	if !strings.Contains(output, "Pool strength (d6): 5 rolls of 20 dice") || !strings.Contains(output, "Faces: 1×") {
+		t.Errorf("stats pool should show the details of the pool:\n%s", output)
+	}
+	if !strings.Contains(output, "missing is not the name of a pool") {
+		t.Errorf("stats pool should report pools that don't exist:\n%s", output)
+	}
+}
+
+func TestReplayHistory(t *testing.T) {
+	// Replaying a log should rebuild the history of each pool
+	tablecommands.SetLogConfig(tablecommands.LogConfig{Dir: t.TempDir()})
+	defer stopLogging()
+
+	table, _ := dice.ParseTableString([]string{}, []string{})
+	table.Name = "HistoryTest"
+	log_dir, err := tablecommands.StartLog(table.Name)
+	if err != nil {
+		t.Fatalf("StartLog returned an error: %v", err)
+	}
+	for _, command := range []string{"add pool strength:4d6", "roll pool strength", "add die strength", "roll table"} {
+		tablecommands.ParseCommand(command, table)
+	}
+
+	file, err := os.Open(filepath.Join(log_dir, "HistoryTest.jsonl"))
+	if err != nil {
+		t.Fatalf("The log file was not created: %v", err)
+	}
+	defer file.Close()
+	replayed, _ := dice.ParseTableString([]string{}, []string{})
+	if _, err := tablecommands.Replay(file, &replayed); err != nil {
+		t.Fatalf("Replay returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(replayed.Pools["strength"].History, table.Pools["strength"].History) {
+		t.Errorf("The replayed history should be %d, instead it is %d", table.Pools["strength"].History, replayed.Pools["strength"].History)
+	}
+}
@@ -0,0 +1,69 @@
+package tablecommands_test
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const initiative_script = `init roll fighter:+3 wizard:-1 goblin:2
+init remove wizard
+next
+next
+prev
+init add troll:1
+delay missing
+view table
+`
+
+func TestInitiative(t *testing.T) {
+	// The order should be rolled, turns should move and count rounds, and the order should be shown with the table
+	table, _ := dice.ParseTableString([]string{}, []string{})
+	var out strings.Builder
+	err := tablecommands.RunScript("initiative.dice", strings.NewReader(initiative_script), &table, &out, true)
+
+	script_err, ok := err.(*tablecommands.ScriptError)
+	if !ok || len(script_err.Failures) != 1 || script_err.Failures[0].Line != 7 {
+		t.Fatalf("Only delaying until after a missing combatant should have failed, instead got %v\n%s", err, out.String())
+	}
+	if table.Initiative == nil || len(table.Initiative.Combatants) != 3 {
+		t.Fatalf("The initiative order should have fighter, goblin and troll:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Round 2 begins.\n") {
+		t.Errorf("The second next should start round 2:\n%s", out.String())
+	}
+	if table.Initiative.Round != 1 || !strings.Contains(out.String(), "Initiative Round 1:\n") {
+		t.Errorf("prev should go back to round 1 and view table should show the order:\n%s", out.String())
+	}
+}
+
+func TestInitiativeReplay(t *testing.T) {
+	// The order, turn and round should come back when the log is replayed
+	tablecommands.SetLogConfig(tablecommands.LogConfig{Dir: t.TempDir()})
+	defer stopLogging()
+
+	table, _ := dice.ParseTableString([]string{}, []string{})
+	table.Name = "InitiativeTest"
+	log_dir, err := tablecommands.StartLog(table.Name)
+	if err != nil {
+		t.Fatalf("StartLog returned an error: %v", err)
+	}
+	var out strings.Builder
+	tablecommands.RunScript("fight.dice", strings.NewReader("init roll a:1 b:2 c:3\nnext\nnext\nnext\nnext\n"), &table, &out, false)
+
+	file, err := os.Open(filepath.Join(log_dir, "InitiativeTest.jsonl"))
+	if err != nil {
+		t.Fatalf("The log file was not created: %v", err)
+	}
+	defer file.Close()
+	replayed, _ := dice.ParseTableString([]string{}, []string{})
+	if _, err := tablecommands.Replay(file, &replayed); err != nil {
+		t.Fatalf("Replay returned an error: %v", err)
+	}
+	if replayed.Initiative == nil || !replayed.Initiative.Equal(table.Initiative) || replayed.Initiative.Round != 2 {
+		t.Errorf("The replayed initiative should match the original, instead it is %+v", replayed.Initiative)
+	}
+}
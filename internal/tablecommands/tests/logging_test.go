@@ -0,0 +1,74 @@
+package tablecommands_test
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogRotation(t *testing.T) {
+	// Logs should be moved aside when they get too big, old files past MaxFiles deleted,
+	// and the new file should still replay to the current table
+	dir := t.TempDir()
+	tablecommands.SetLogConfig(tablecommands.LogConfig{Dir: dir, MaxSize: 600, MaxFiles: 2})
+	defer stopLogging()
+
+	table, _ := dice.ParseTableString([]string{"4d6", "2d6"}, []string{"strength", "agility"})
+	table.Name = "Rotate"
+	if _, err := tablecommands.StartLog(table.Name); err != nil {
+		t.Fatalf("StartLog returned an error: %v", err)
+	}
+	for n := 0; n < 20; n++ {
+		tablecommands.ParseCommand("roll table", table)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	rotated := 0
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "Rotate.") && entry.Name() != "Rotate.jsonl" {
+			rotated++
+		}
+		info, _ := entry.Info()
+		if info.Mode().Perm()&0077 != 0 {
+			t.Errorf("%s can be read by other users, its permissions are %v", entry.Name(), info.Mode().Perm())
+		}
+	}
+	if rotated != 2 {
+		t.Errorf("There should be 2 old log files kept, instead there are %d", rotated)
+	}
+
+	file, err := os.Open(filepath.Join(dir, "Rotate.jsonl"))
+	if err != nil {
+		t.Fatalf("The current log file is missing: %v", err)
+	}
+	defer file.Close()
+	replayed := dice.Table{Pools: make(map[string]*dice.Pool)}
+	if _, err := tablecommands.Replay(file, &replayed); err != nil {
+		t.Fatalf("Replay returned an error: %v", err)
+	}
+	for name, pool := range table.Pools {
+		if replayed.Pools[name] == nil || replayed.Pools[name].Total() != pool.Total() {
+			t.Errorf("Replaying the current log file should rebuild pool %s", name)
+		}
+	}
+}
+
+func TestLogDisabled(t *testing.T) {
+	// When logging is turned off nothing should be written
+	dir := filepath.Join(t.TempDir(), "logs")
+	tablecommands.SetLogConfig(tablecommands.LogConfig{Dir: dir, Disabled: true})
+	defer stopLogging()
+
+	table, _ := dice.ParseTableString([]string{"4d6"}, []string{"strength"})
+	log_dir, err := tablecommands.StartLog("Disabled")
+	if err != nil || log_dir != "" {
+		t.Errorf("StartLog should return no directory and no error, instead returned %q %v", log_dir, err)
+	}
+	tablecommands.ParseCommand("roll table", table)
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("The log directory should not have been created")
+	}
+}
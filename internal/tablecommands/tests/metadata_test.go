@@ -0,0 +1,78 @@
+package tablecommands_test
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const metadata_script = `add pool sword:2d6 fireball:8d6 luck:1d20
+tag pool sword combat
+tag pool fireball combat magic
+describe pool fireball "Third level spell"
+rename pool sword longsword
+roll tag:combat
+view pool tag:magic
+untag pool fireball combat
+tag
+roll tag:nothing
+`
+
+func TestMetadata(t *testing.T) {
+	// Pools should be renamed, described and tagged, and tags should pick pools out for other commands
+	table, _ := dice.ParseTableString([]string{}, []string{})
+	var out strings.Builder
+	err := tablecommands.RunScript("metadata.dice", strings.NewReader(metadata_script), &table, &out, true)
+
+	script_err, ok := err.(*tablecommands.ScriptError)
+	if !ok || len(script_err.Failures) != 1 || script_err.Failures[0].Line != 10 {
+		t.Fatalf("Only rolling a tag no pool has should have failed, instead got %v\n%s", err, out.String())
+	}
+	longsword, ok := table.Pools["longsword"]
+	if !ok || !reflect.DeepEqual(longsword.Tags, []string{"combat"}) {
+		t.Fatalf("sword should have been renamed to longsword and kept its tag:\n%s", out.String())
+	}
+	if len(longsword.History) != 1 || len(table.Pools["fireball"].History) != 1 || len(table.Pools["luck"].History) != 0 {
+		t.Errorf("roll tag:combat should only have rolled longsword and fireball:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Third level spell Tags: combat, magic.\n") {
+		t.Errorf("view should show the description and tags:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Tags:\ncombat: longsword\nmagic: fireball\n") {
+		t.Errorf("tag should list every tag and its pools:\n%s", out.String())
+	}
+}
+
+func TestMetadataReplay(t *testing.T) {
+	// Descriptions, tags and the history of renamed pools should come back when the log is replayed
+	tablecommands.SetLogConfig(tablecommands.LogConfig{Dir: t.TempDir()})
+	defer stopLogging()
+
+	table, _ := dice.ParseTableString([]string{}, []string{})
+	table.Name = "MetadataTest"
+	log_dir, err := tablecommands.StartLog(table.Name)
+	if err != nil {
+		t.Fatalf("StartLog returned an error: %v", err)
+	}
+	for _, command := range []string{"add pool sword:2d6", "roll pool sword", "tag pool sword combat", "describe pool sword Sharp", "rename pool sword blade"} {
+		tablecommands.ParseCommand(command, table)
+	}
+
+	file, err := os.Open(filepath.Join(log_dir, "MetadataTest.jsonl"))
+	if err != nil {
+		t.Fatalf("The log file was not created: %v", err)
+	}
+	defer file.Close()
+	replayed, _ := dice.ParseTableString([]string{}, []string{})
+	if _, err := tablecommands.Replay(file, &replayed); err != nil {
+		t.Fatalf("Replay returned an error: %v", err)
+	}
+	blade, ok := replayed.Pools["blade"]
+	if !ok || blade.Description != "Sharp" || !blade.HasTag("combat") || len(blade.History) != 1 {
+		t.Errorf("The replayed pool should match the original, instead it is %+v", blade)
+	}
+}
@@ -0,0 +1,38 @@
+package tablecommands_test
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"strings"
+	"testing"
+)
+
+func TestReroll(t *testing.T) {
+	// reroll should report which dice changed and from what
+	table, _ := dice.ParseTableString([]string{"4d6"}, []string{"strength"})
+	table.Pools["strength"].Dice[2].Top = 5
+
+	output := tablecommands.ParseCommand("reroll pool strength 1", table)
+	if !strings.Contains(output, "Rerolled pool strength:\nDie 0: 1 -> ") || strings.Contains(output, "Die 2:") {
+		t.Errorf("reroll should list the dice that showed 1 and not the 5:\n%s", output)
+	}
+	if table.Pools["strength"].Dice[2].Top != 5 {
+		t.Errorf("The die showing 5 should not have been rerolled")
+	}
+
+	output = tablecommands.ParseCommand("reroll pool strength die 2", table)
+	if !strings.Contains(output, "Die 2: 5 -> ") || strings.Contains(output, "Die 0:") {
+		t.Errorf("reroll die should only reroll the die at the position:\n%s", output)
+	}
+
+	output = tablecommands.ParseCommand("reroll pool strength >6", table)
+	if !strings.Contains(output, "No dice in pool strength matched") {
+		t.Errorf("reroll should say when no dice matched:\n%s", output)
+	}
+
+	for _, command := range []string{"reroll pool strength die 0 --recursive", "reroll pool strength <7 -r", "reroll pool strength die 9", "reroll pool missing 1"} {
+		if output := tablecommands.ParseCommand(command, table); strings.Contains(output, "Rerolled") {
+			t.Errorf("%s should have failed:\n%s", command, output)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package tablecommands_test
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"strings"
+	"testing"
+)
+
+const setup = `# Set up the pools for a session
+add pool strength:4d6   # the main pool
+add pool "Fire Bolt:8d6"
+
+add die strength \
+	"Fire Bolt"
+roll pool missing
+roll pool strength
+`
+
+func TestRunScriptStopsOnError(t *testing.T) {
+	// A script should stop at the first command that fails and report the line it was on
+	table, _ := dice.ParseTableString([]string{}, []string{})
+	var out strings.Builder
+	err := tablecommands.RunScript("setup.dice", strings.NewReader(setup), &table, &out, false)
+
+	script_err, ok := err.(*tablecommands.ScriptError)
+	if !ok {
+		t.Fatalf("RunScript should have returned a *ScriptError, instead returned %v", err)
+	}
+	if len(script_err.Failures) != 1 || script_err.Failures[0].Line != 7 {
+		t.Errorf("The failure should have been on line 7, instead got %+v", script_err.Failures)
+	}
+	if len(table.Pools["strength"].Dice) != 5 || len(table.Pools["Fire Bolt"].Dice) != 9 {
+		t.Errorf("The continued add die line should have added a die to both pools")
+	}
+	if strings.Contains(out.String(), "roll pool strength") {
+		t.Errorf("The script should have stopped before rolling strength:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "setup.dice:7:") {
+		t.Errorf("The output should say where the script failed:\n%s", out.String())
+	}
+}
+
+func TestRunScriptContinue(t *testing.T) {
+	// With keep_going the script should run every line and still report the failure
+	table, _ := dice.ParseTableString([]string{}, []string{})
+	var out strings.Builder
+	err := tablecommands.RunScript("setup.dice", strings.NewReader(setup+"exit\nroll pool nothing\n"), &table, &out, true)
+
+	script_err, ok := err.(*tablecommands.ScriptError)
+	if !ok || len(script_err.Failures) != 1 {
+		t.Fatalf("RunScript should have reported one failure, instead returned %v", err)
+	}
+	if !strings.Contains(out.String(), "roll pool strength") {
+		t.Errorf("The script should have kept going after the failure:\n%s", out.String())
+	}
+
+	err = tablecommands.RunScript("empty.dice", strings.NewReader("# nothing here\n\n"), &table, &out, false)
+	if err != nil {
+		t.Errorf("A script with only comments should not fail: %v", err)
+	}
+}
+
+func TestExec(t *testing.T) {
+	// Exec should run every command in one session, print only their output and report the ones that failed
+	tablecommands.SetLogConfig(tablecommands.LogConfig{Disabled: true})
+	table, _ := dice.ParseTableString([]string{}, []string{})
+	var out, errs strings.Builder
+	commands := []string{"add pool strength:4d6", "r 2d6", "capture damage", "roll pool nope", "view pool damage"}
+	tables, err := tablecommands.Exec(commands, &table, &out, &errs)
+
+	script_err, ok := err.(*tablecommands.ScriptError)
+	if !ok || len(script_err.Failures) != 1 || script_err.Failures[0].Line != 4 {
+		t.Fatalf("Only rolling nope should have failed, instead got %v\n%s", err, errs.String())
+	}
+	if strings.Contains(out.String(), ":>") {
+		t.Errorf("Exec should not echo the commands:\n%s", out.String())
+	}
+	if _, ok := table.Pools["damage"]; !ok {
+		t.Errorf("capture should have used the roll from the command before it:\n%s", out.String())
+	}
+	if errs.String() != "Pool nope does not exist.\n    roll pool nope\n              ^^^^\n" {
+		t.Errorf("The failure should be written to errs pointing at the pool, instead got %q", errs.String())
+	}
+	if len(tables) != 1 || tables[0] != &table {
+		t.Errorf("Exec should return the table it was given, instead got %v", tables)
+	}
+
+	// Tables made or switched to by the commands should be returned too so they can be saved
+	out.Reset()
+	tables, err = tablecommands.Exec([]string{"table new camp", "add pool torches:2d6"}, &table, &out, &errs)
+	if err != nil || len(tables) != 2 || tables[1].Name != "camp" || tables[1].Pools["torches"] == nil {
+		t.Errorf("Exec should return the new table with its pool, instead got %v %v", tables, err)
+	}
+}
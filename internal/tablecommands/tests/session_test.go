@@ -0,0 +1,123 @@
+package tablecommands_test
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSession(t *testing.T) {
+	// A session should run each line of its input, write the results to its output and stop at exit
+	table := dice.Table{Pools: make(map[string]*dice.Pool), Name: "Embedded"}
+	input := "add pool strength:4d6\nroll pool missing\nexit\nadd pool agility:2d6\n"
+	var out strings.Builder
+	session := tablecommands.NewSession(&table, strings.NewReader(input), &out, nil, nil)
+
+	if err := session.Run(); err != nil {
+		t.Fatalf("Run returned an error: %v", err)
+	}
+	if table.Pools["strength"] == nil || len(table.Pools["strength"].Dice) != 4 {
+		t.Errorf("The session should have added strength to the table")
+	}
+	if table.Pools["agility"] != nil {
+		t.Errorf("The session should have stopped at exit before adding agility")
+	}
+	if !strings.Contains(out.String(), "Embedded:> ") || !strings.HasSuffix(out.String(), "Goodbye...\n") {
+		t.Errorf("The output should have the prompt and end with Goodbye...:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "missing") {
+		t.Errorf("The output should say the missing pool couldn't be rolled:\n%s", out.String())
+	}
+
+	// The session should also end without an error when the input runs out
+	out.Reset()
+	session = tablecommands.NewSession(&table, strings.NewReader("clear pool strength\nn\n"), &out, nil, nil)
+	if err := session.Run(); err != nil {
+		t.Fatalf("Run returned an error: %v", err)
+	}
+	if table.Pools["strength"] == nil {
+		t.Errorf("Answering no should have kept strength:\n%s", out.String())
+	}
+	if _, err := session.Exec("exit"); err != tablecommands.ErrExit {
+		t.Errorf("Exec of exit should return ErrExit, instead returned %v", err)
+	}
+}
+
+func TestSessionLoggerAndStore(t *testing.T) {
+	// A session should log to the logger and save to the store it was given rather than those set for the package
+	dir := t.TempDir()
+	logger, err := tablecommands.NewLogger(tablecommands.LogConfig{Dir: filepath.Join(dir, "logs")})
+	if err != nil {
+		t.Fatalf("NewLogger returned an error: %v", err)
+	}
+	defer logger.Close()
+	store := &tablecommands.Store{Dir: filepath.Join(dir, "tables")}
+
+	table := dice.Table{Pools: make(map[string]*dice.Pool), Name: "Logged"}
+	var out strings.Builder
+	session := tablecommands.NewSession(&table, strings.NewReader("add pool strength:4d6\ntable save\n"), &out, logger, store)
+	if err := session.Run(); err != nil {
+		t.Fatalf("Run returned an error: %v", err)
+	}
+	if session.Table() != &table {
+		t.Errorf("Table should return the table the session was started with")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "logs", "Logged.jsonl"))
+	if err != nil || !strings.Contains(string(data), `"command":"add"`) {
+		t.Errorf("The add command should have been logged, the log is %q %v", data, err)
+	}
+	saved, err := store.Load("Logged")
+	if err != nil || saved.Pools["strength"] == nil {
+		t.Errorf("The table should have been saved to the store, loaded %v %v", saved.Pools, err)
+	}
+}
+
+func TestSessionHistoryOnlyAtTerminal(t *testing.T) {
+	// Commands that aren't typed at a terminal shouldn't be saved to the prompt history
+	dir := t.TempDir()
+	logger, err := tablecommands.NewLogger(tablecommands.LogConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("NewLogger returned an error: %v", err)
+	}
+	defer logger.Close()
+
+	table := dice.Table{Pools: make(map[string]*dice.Pool), Name: "Piped"}
+	var out strings.Builder
+	session := tablecommands.NewSession(&table, strings.NewReader("add pool strength:4d6\nroll table\n"), &out, logger, nil)
+	if err := session.Run(); err != nil {
+		t.Fatalf("Run returned an error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".history")); !os.IsNotExist(err) {
+		t.Errorf("A session reading from a pipe shouldn't write a history file, Stat returned %v", err)
+	}
+}
+
+func TestLoggerShared(t *testing.T) {
+	// A logger should be safe to open and close logs on from more than one goroutine
+	logger, err := tablecommands.NewLogger(tablecommands.LogConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewLogger returned an error: %v", err)
+	}
+	done := make(chan error)
+	for n := 0; n < 4; n++ {
+		go func(name string) {
+			for i := 0; i < 20; i++ {
+				if err := logger.Open(name); err != nil {
+					done <- err
+					return
+				}
+				logger.Close()
+			}
+			done <- nil
+		}(strings.Repeat("t", n+1))
+	}
+	for n := 0; n < 4; n++ {
+		if err := <-done; err != nil {
+			t.Errorf("Open returned an error: %v", err)
+		}
+	}
+}
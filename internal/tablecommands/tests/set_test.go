@@ -0,0 +1,80 @@
+package tablecommands_test
+
+import (
+	"bufio"
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	// set should set one die, a whole pool, each die in a pool, or the whole table
+	table, _ := dice.ParseTableString([]string{"4d6", "2d20"}, []string{"strength", "luck"})
+
+	tablecommands.ParseCommand("set pool strength 4", table)
+	if !reflect.DeepEqual(table.Pools["strength"].List(), []int{4, 4, 4, 4}) {
+		t.Errorf("set pool should set every die, instead strength is %d", table.Pools["strength"].List())
+	}
+	tablecommands.ParseCommand("set pool strength 3,5,6,1", table)
+	if !reflect.DeepEqual(table.Pools["strength"].List(), []int{3, 5, 6, 1}) {
+		t.Errorf("set pool with a list should set each die in order, instead strength is %d", table.Pools["strength"].List())
+	}
+	tablecommands.ParseCommand("set table 2", table)
+	if !reflect.DeepEqual(table.Pools["luck"].List(), []int{2, 2}) {
+		t.Errorf("set table should set every die on the table, instead luck is %d", table.Pools["luck"].List())
+	}
+
+	// Lists that don't fit the pool should fail without changing it unless --resize is given
+	for _, command := range []string{"set pool strength 1,2,3", "set pool strength 1,2,3,7", "set die strength 9 1", "set table 7"} {
+		output := tablecommands.ParseCommand(command, table)
+		if strings.Contains(output, "Successfully") {
+			t.Errorf("%s should have failed:\n%s", command, output)
+		}
+	}
+	if !reflect.DeepEqual(table.Pools["strength"].List(), []int{2, 2, 2, 2}) {
+		t.Errorf("Failed sets should not change the pool, instead strength is %d", table.Pools["strength"].List())
+	}
+	tablecommands.ParseCommand("set pool strength 6,6,6,6,6 --resize", table)
+	if !reflect.DeepEqual(table.Pools["strength"].List(), []int{6, 6, 6, 6, 6}) {
+		t.Errorf("--resize should add a die for the extra face, instead strength is %d", table.Pools["strength"].List())
+	}
+}
+
+func TestSetLoggedAsManual(t *testing.T) {
+	// Faces set by hand should be marked manual in the log and rolls should not
+	tablecommands.SetLogConfig(tablecommands.LogConfig{Dir: t.TempDir()})
+	defer stopLogging()
+
+	table, _ := dice.ParseTableString([]string{"4d6"}, []string{"strength"})
+	table.Name = "ManualTest"
+	log_dir, err := tablecommands.StartLog(table.Name)
+	if err != nil {
+		t.Fatalf("StartLog returned an error: %v", err)
+	}
+	tablecommands.ParseCommand("roll pool strength", table)
+	tablecommands.ParseCommand("set pool strength 3,5,6,1", table)
+
+	file, err := os.Open(filepath.Join(log_dir, "ManualTest.jsonl"))
+	if err != nil {
+		t.Fatalf("The log file was not created: %v", err)
+	}
+	defer file.Close()
+	var events []tablecommands.Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event tablecommands.Event
+		json.Unmarshal(scanner.Bytes(), &event)
+		events = append(events, event)
+	}
+	if len(events) != 2 || events[0].Manual || !events[1].Manual {
+		t.Fatalf("Only the set event should be manual, instead got %+v", events)
+	}
+	if after := events[1].Changes[0].After; !reflect.DeepEqual(after.Faces, []int{3, 5, 6, 1}) {
+		t.Errorf("The set event should record the faces that were entered, instead it has %d", after.Faces)
+	}
+}
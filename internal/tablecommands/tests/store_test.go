@@ -0,0 +1,106 @@
+package tablecommands_test
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStoreSaveLoad(t *testing.T) {
+	// A saved table should load with the same faces, history, counters and initiative order
+	store := &tablecommands.Store{Dir: t.TempDir()}
+	table, _ := dice.ParseTableString([]string{"4d6"}, []string{"strength"})
+	table.Name = "camp"
+	table.Pools["strength"].Roll()
+	table.Pools["strength"].Tag("combat")
+	clock, _ := dice.NewClock(4)
+	clock.Set(2)
+	table.AddCounter("alarm", clock)
+	table.Initiative, _ = dice.RollInitiative([]string{"goblin", "wizard"}, []int{2, 0})
+
+	if err := store.Save(&table); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := store.Load("camp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := loaded.Pools["strength"]
+	if !reflect.DeepEqual(pool.List(), table.Pools["strength"].List()) || len(pool.History) != 1 || !pool.HasTag("combat") {
+		t.Errorf("strength should load as it was saved, instead got %s %v", pool.Describe(), pool.History)
+	}
+	if !loaded.Counters["alarm"].Equal(clock) {
+		t.Errorf("The clock should load as %s, instead got %v", clock, loaded.Counters["alarm"])
+	}
+	if !loaded.Initiative.Equal(table.Initiative) {
+		t.Errorf("The initiative order should load as it was saved")
+	}
+}
+
+func TestStoreList(t *testing.T) {
+	// Saved tables should be listed by name and missing ones should be ErrNoTable
+	store := &tablecommands.Store{Dir: t.TempDir()}
+	for _, name := range []string{"dungeon", "camp"} {
+		table, _ := dice.CreateTable(nil, nil)
+		table.Name = name
+		store.Save(&table)
+	}
+	files, err := store.List()
+	if err != nil || len(files) != 2 || files[0].Name != "camp" || files[1].Name != "dungeon" {
+		t.Errorf("List should return camp and dungeon, instead got %v %v", files, err)
+	}
+	if _, err := store.Load("town"); !errors.Is(err, tablecommands.ErrNoTable) {
+		t.Errorf("Loading a table that was never saved should be ErrNoTable, instead got %v", err)
+	}
+	if err := store.Delete("camp"); err != nil {
+		t.Error(err)
+	}
+	if _, err := store.Load("camp"); !errors.Is(err, tablecommands.ErrNoTable) {
+		t.Errorf("A deleted table should not load, instead got %v", err)
+	}
+}
+
+func TestTableSaveCommand(t *testing.T) {
+	// table save and table load should keep a table between sessions
+	tablecommands.SetLogConfig(tablecommands.LogConfig{Disabled: true})
+	tablecommands.SetStore(&tablecommands.Store{Dir: t.TempDir()})
+	defer tablecommands.SetStore(nil)
+
+	table, _ := dice.ParseTableString([]string{"4d6"}, []string{"strength"})
+	table.Name = "camp"
+	var out strings.Builder
+	err := tablecommands.RunScript("save.dice", strings.NewReader("table save\ntable saved\n"), &table, &out, false)
+	if err != nil {
+		t.Fatalf("Saving should work, instead got %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "camp: 1 pools") {
+		t.Errorf("table saved should list camp:\n%s", out.String())
+	}
+
+	other, _ := dice.CreateTable(nil, nil)
+	out.Reset()
+	err = tablecommands.RunScript("load.dice", strings.NewReader("table load camp\nview pool strength\n"), &other, &out, false)
+	if err != nil || !strings.Contains(out.String(), "A pool of 4 d6s") {
+		t.Errorf("table load should switch to the saved table, instead got %v\n%s", err, out.String())
+	}
+}
+
+func TestTableSaveDryRun(t *testing.T) {
+	// A dry run of table save should say what it would save without writing anything
+	store := &tablecommands.Store{Dir: t.TempDir()}
+	tablecommands.SetStore(store)
+	defer tablecommands.SetStore(nil)
+
+	table, _ := dice.ParseTableString([]string{"4d6"}, []string{"strength"})
+	table.Name = "camp"
+	output, err := tablecommands.RunCommand("table save --dry-run", &table)
+	if err != nil || !strings.Contains(output, "Would save table camp.") {
+		t.Errorf("The dry run should say it would save camp, instead got %v\n%s", err, output)
+	}
+	if files, err := store.List(); err != nil || len(files) != 0 {
+		t.Errorf("The dry run shouldn't have saved anything, the store has %v %v", files, err)
+	}
+}
@@ -1 +1,78 @@
 package tablecommands_test
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	// --dry-run should describe what a command would change without changing the table
+	table, _ := dice.ParseTableString([]string{"4d6", "2d6"}, []string{"strength", "agility"})
+
+	output := tablecommands.ParseCommand("clear table --dry-run", table)
+	if len(table.Pools) != 2 {
+		t.Errorf("clear table --dry-run removed pools from the table")
+	}
+	if !strings.Contains(output, "- pool strength: 4d6") || !strings.Contains(output, "- pool agility: 2d6") {
+		t.Errorf("The dry run should list the pools that would be removed, instead got:\n%s", output)
+	}
+
+	output = tablecommands.ParseCommand("add --dry-run die strength", table)
+	if len(table.Pools["strength"].Dice) != 4 || !strings.Contains(output, "~ pool strength: 4d6 [1 1 1 1] -> 5d6") {
+		t.Errorf("add die --dry-run should not add the die but show it, instead got:\n%s", output)
+	}
+
+	output = tablecommands.ParseCommand("view table --dry-run", table)
+	if !strings.Contains(output, "The table would not change.") {
+		t.Errorf("A dry run of view should say nothing would change, instead got:\n%s", output)
+	}
+}
+
+func TestOptionsEndMarker(t *testing.T) {
+	// Arguments after -- should be used as pool names even if they look like options
+	table, _ := dice.ParseTableString([]string{}, []string{})
+	tablecommands.ParseCommand("add pool -- -y:2d6", table)
+	if _, ok := table.Pools["-y"]; !ok {
+		t.Errorf("add pool -- -y:2d6 should have added a pool named -y")
+	}
+}
+
+func TestConfig(t *testing.T) {
+	// config should list settings and reject values that aren't on or off
+	table, _ := dice.ParseTableString([]string{}, []string{})
+	if output := tablecommands.ParseCommand("config", table); !strings.Contains(output, "confirm = on") {
+		t.Errorf("config should list confirm as on, instead got:\n%s", output)
+	}
+	if output := tablecommands.ParseCommand("config confirm maybe", table); !strings.Contains(output, "not on or off") {
+		t.Errorf("config confirm maybe should have failed, instead got:\n%s", output)
+	}
+}
+
+func TestClearPool(t *testing.T) {
+	// clear pool should remove every die from each pool, however many there are
+	table, _ := dice.ParseTableString([]string{"6d6", "1d20", "0d4"}, []string{"horde", "one", "none"})
+	output := tablecommands.ParseCommand("clear pool horde one none", table)
+	for name, pool := range table.Pools {
+		if len(pool.Dice) != 0 {
+			t.Errorf("clear pool should have removed every die from %s, %d are left:\n%s", name, len(pool.Dice), output)
+		}
+	}
+}
+
+func TestSubtractConfirmed(t *testing.T) {
+	// Subtracting dice removes them like clear does, so it should be confirmed too
+	table, _ := dice.ParseTableString([]string{"4d6"}, []string{"strength"})
+	var out strings.Builder
+	session := tablecommands.NewSession(&table, strings.NewReader("subtract die strength:2\nn\nsubtract die strength:2 -y\n"), &out, nil, nil)
+	if err := session.Run(); err != nil {
+		t.Fatalf("Run returned an error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Are you sure?") || !strings.Contains(out.String(), "Cancelled. Nothing was changed.") {
+		t.Errorf("subtract die should have asked before removing dice:\n%s", out.String())
+	}
+	if len(table.Pools["strength"].Dice) != 2 {
+		t.Errorf("Only the subtract given -y should have removed dice, strength has %d:\n%s", len(table.Pools["strength"].Dice), out.String())
+	}
+}
@@ -0,0 +1,57 @@
+package tablecommands_test
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"strings"
+	"testing"
+)
+
+const tables_script = `add pool strength:4d6
+table new dungeon
+add pool goblins:3d8
+copy pool goblins "" orcs
+table use ""
+move pool strength dungeon might
+table copy dungeon backup
+table list
+table delete backup
+table use backup
+`
+
+func TestTables(t *testing.T) {
+	// Commands should run against the active table and pools should move between tables
+	table, _ := dice.ParseTableString([]string{}, []string{})
+	var out strings.Builder
+	err := tablecommands.RunScript("tables.dice", strings.NewReader(tables_script), &table, &out, true)
+
+	script_err, ok := err.(*tablecommands.ScriptError)
+	if !ok || len(script_err.Failures) != 1 || script_err.Failures[0].Line != 10 {
+		t.Fatalf("Only switching to the deleted table should have failed, instead got %v\n%s", err, out.String())
+	}
+	if _, ok := table.Pools["orcs"]; !ok {
+		t.Errorf("goblins should have been copied to the first table as orcs")
+	}
+	if _, ok := table.Pools["strength"]; ok {
+		t.Errorf("strength should have been moved off the first table")
+	}
+	if !strings.Contains(out.String(), "* \"\": 1 pools") || !strings.Contains(out.String(), "  backup: 2 pools") {
+		t.Errorf("table list should show every table and mark the active one:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "dungeon:> add pool goblins:3d8") {
+		t.Errorf("The prompt should show the active table:\n%s", out.String())
+	}
+}
+
+func TestTableDeleteDryRun(t *testing.T) {
+	// A dry run of deleting a table should list the pools that would go with it
+	table, _ := dice.ParseTableString([]string{"4d6"}, []string{"strength"})
+	var out strings.Builder
+	tablecommands.RunScript("delete.dice", strings.NewReader("table copy \"\" other\ntable delete other --dry-run\ntable list\n"), &table, &out, false)
+	if !strings.Contains(out.String(), "- table other\n  - pool strength: 4d6") {
+		t.Errorf("The dry run should show the table and its pools being removed:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "other: 1 pools") {
+		t.Errorf("The dry run should not have deleted the table:\n%s", out.String())
+	}
+}
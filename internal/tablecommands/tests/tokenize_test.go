@@ -0,0 +1,133 @@
+package tablecommands_test
+
+import (
+	"dicetable/internal/tablecommands"
+	"dicetable/pkg/dice"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	// Tokenize should split on any whitespace and handle quotes and escapes like a shell
+	cases := map[string][]string{
+		"roll pool strength":              {"roll", "pool", "strength"},
+		"  roll   pool\tstrength  ":       {"roll", "pool", "strength"},
+		`add pool "Fire Bolt:8d6"`:        {"add", "pool", "Fire Bolt:8d6"},
+		`add pool "Fire Bolt":8d6`:        {"add", "pool", "Fire Bolt:8d6"},
+		`roll pool Fire\ Bolt`:            {"roll", "pool", "Fire Bolt"},
+		`view pool 'it\'s'`:               nil,
+		`view pool 'a "quoted" name'`:     {"view", "pool", `a "quoted" name`},
+		`view pool "say \"hi\" \\ there"`: {"view", "pool", `say "hi" \ there`},
+		`view pool ""`:                    {"view", "pool", ""},
+		"":                                {},
+	}
+
+	for input, expected := range cases {
+		args, err := tablecommands.Tokenize(input)
+		if expected == nil {
+			if err == nil {
+				t.Errorf("Tokenize(%q) should have returned an error but returned %q", input, args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Tokenize(%q) returned an error: %v", input, err)
+			continue
+		}
+		if len(args) == 0 && len(expected) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(args, expected) {
+			t.Errorf("Tokenize(%q) returned %q, expected %q", input, args, expected)
+		}
+	}
+}
+
+func TestTokenizeErrorPosition(t *testing.T) {
+	// Errors from Tokenize should point at the opening quote of an unterminated string
+	_, err := tablecommands.Tokenize(`add pool "Fire Bolt:8d6`)
+	syntax_err, ok := err.(*tablecommands.SyntaxError)
+	if !ok {
+		t.Fatalf("Tokenize should have returned a *SyntaxError, instead returned %v", err)
+	}
+	if syntax_err.Pos != 9 {
+		t.Errorf("The error should point at column 9, instead it points at %d", syntax_err.Pos)
+	}
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != 3 || strings.Index(lines[2], "^") != strings.Index(lines[1], `"`) {
+		t.Errorf("The error message should mark the opening quote, instead it was:\n%s", err)
+	}
+
+	_, err = tablecommands.Tokenize(`roll pool strength\`)
+	if err == nil {
+		t.Errorf("A trailing backslash should return an error")
+	}
+}
+
+func TestParseCommandQuotedNames(t *testing.T) {
+	// Pools with spaces in their names can be added and used through ParseCommand
+	table, _ := dice.ParseTableString([]string{}, []string{})
+	tablecommands.ParseCommand(`add pool "Fire Bolt:8d6"`, table)
+	if _, ok := table.Pools["Fire Bolt"]; !ok {
+		t.Fatalf("add pool did not create a pool named Fire Bolt")
+	}
+	output := tablecommands.ParseCommand(`roll   pool	"Fire Bolt"`, table)
+	if strings.Contains(output, "does not exist") {
+		t.Errorf("roll pool could not find the Fire Bolt pool: %s", output)
+	}
+
+	output = tablecommands.ParseCommand("rol pool strength", table)
+	if !strings.Contains(output, "^^^") {
+		t.Errorf("An invalid command should be marked in the output, instead got %s", output)
+	}
+}
+
+func pointedAt(input string, arg string) string {
+	// The input with carets under the first place arg is in it
+	return "    " + input + "\n    " + strings.Repeat(" ", strings.Index(input, arg)) + strings.Repeat("^", len(arg))
+}
+
+func TestArgumentErrorsPointAtArgs(t *testing.T) {
+	// An error about one argument should point at it in the input, or at the part of it that is wrong
+	cases := []struct {
+		input string
+		arg   string
+	}{
+		{"roll pool horde nope", "nope"},
+		{"add pool fire:4x6", "4x6"},
+		{"set die horde 9 3", "9"},
+		{"set pool horde six", "six"},
+		{"set table six", "six"},
+		{"subtract die horde:two", "two"},
+		{"view pool nope", "nope"},
+		{`roll pool "no such"`, `"no such"`},
+		{"table use nowhere", "nowhere"},
+		{"move pool horde nowhere", "nowhere"},
+		{"counter inc nope", "nope"},
+		{"counter new hp 10 --min x", "x"},
+		{"reroll pool horde die 1,x", "x"},
+		{"tag pool nope hard", "nope"},
+		{"rename pool horde tag:hard", "tag:hard"},
+		{"init roll goblin:fast", "fast"},
+		{"history pool horde many", "many"},
+		{"config display fancy", "fancy"},
+		{"unalias nope", "nope"},
+		{"sort pool nope", "nope"},
+	}
+	for _, c := range cases {
+		table, _ := dice.ParseTableString([]string{"6d6"}, []string{"horde"})
+		output := tablecommands.ParseCommand(c.input, table)
+		if want := pointedAt(c.input, c.arg); !strings.Contains(output, want) {
+			t.Errorf("%s should point at %s:\n%s\ninstead got\n%s", c.input, c.arg, want, output)
+		}
+	}
+
+	// An argument that came from an alias isn't in the input, so there is nothing to point at
+	table, _ := dice.ParseTableString([]string{"6d6"}, []string{"horde"})
+	var out strings.Builder
+	tablecommands.RunScript("alias.dice", strings.NewReader("alias rn roll pool nope\nrn\n"), &table, &out, true)
+	if !strings.Contains(out.String(), "Pool nope does not exist.") || strings.Contains(out.String(), "^") {
+		t.Errorf("An error about an argument from an alias shouldn't point at anything:\n%s", out.String())
+	}
+}
@@ -0,0 +1,200 @@
+package tablecommands
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type token struct {
+	Text string
+	Pos  int // rune offset of the first character of the token in the input
+	End  int // rune offset just past the last character of the token
+}
+
+type SyntaxError struct {
+	Input string
+	Pos   int
+	Width int
+	Msg   string
+}
+
+func (err *SyntaxError) Error() string {
+	// Return the message followed by the input with a marker under the offending characters
+	return fmt.Sprintf("%s at column %d:\n%s", err.Msg, err.Pos+1, pointAt(err.Input, err.Pos, err.Width))
+}
+
+func pointAt(input string, pos int, width int) string {
+	// Return the input with a line of carets under the characters from pos to pos+width
+	if width < 1 {
+		width = 1
+	}
+	// Tabs are kept in the padding so the marker lines up in a terminal
+	var pad strings.Builder
+	for n, r := range []rune(input) {
+		if n >= pos {
+			break
+		}
+		if r == '\t' {
+			pad.WriteRune('\t')
+		} else {
+			pad.WriteRune(' ')
+		}
+	}
+	return fmt.Sprintf("    %s\n    %s%s", input, pad.String(), strings.Repeat("^", width))
+}
+
+// An argError is an error caused by one argument of a command. run points at the argument in the input
+type argError struct {
+	arg string
+	msg string
+}
+
+func (err *argError) Error() string {
+	return err.msg
+}
+
+func argErrorf(arg string, format string, a ...interface{}) error {
+	// Return an error about arg, or about part of it like the dice of name:XdY
+	return &argError{arg: arg, msg: fmt.Sprintf(format, a...)}
+}
+
+// An errorList is the errors from each argument of a command, one to a line
+type errorList []error
+
+func (errs errorList) Error() string {
+	msgs := make([]string, len(errs))
+	for n, err := range errs {
+		msgs[n] = strings.TrimRight(err.Error(), "\n")
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func pointAtArgs(err error, input string, tokens []token) error {
+	// Add a marker under the argument each argError is about. An argument that isn't in the input,
+	// like one that came from an alias or a tag, is left without one
+	switch e := err.(type) {
+	case errorList:
+		pointed := make(errorList, len(e))
+		for n, err := range e {
+			pointed[n] = pointAtArgs(err, input, tokens)
+		}
+		return pointed
+	case *argError:
+		if pos, width, ok := findArg(input, tokens, e.arg); ok {
+			return fmt.Errorf("%w\n%s", err, pointAt(input, pos, width))
+		}
+	}
+	return err
+}
+
+func findArg(input string, tokens []token, arg string) (int, int, bool) {
+	// Find the token that is arg, or failing that the first one with arg in it
+	if arg == "" {
+		return 0, 0, false
+	}
+	for _, t := range tokens {
+		if t.Text == arg {
+			return t.Pos, t.End - t.Pos, true
+		}
+	}
+	runes := []rune(input)
+	for _, t := range tokens {
+		i := strings.Index(t.Text, arg)
+		if i < 0 {
+			continue
+		}
+		// Point at just the part when the token was typed without quotes or escapes, otherwise at all of it
+		if string(runes[t.Pos:t.End]) == t.Text {
+			return t.Pos + len([]rune(t.Text[:i])), len([]rune(arg)), true
+		}
+		return t.Pos, t.End - t.Pos, true
+	}
+	return 0, 0, false
+}
+
+func Tokenize(input string) ([]string, error) {
+	// Split a command line into arguments the same way a shell would.
+	// Any amount of whitespace separates arguments, single quotes keep everything inside them literally,
+	// double quotes keep everything but backslash escapes, and a backslash outside of quotes escapes the next character.
+	// A # at the start of an argument makes the rest of the line a comment.
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, len(tokens))
+	for n, t := range tokens {
+		args[n] = t.Text
+	}
+	return args, nil
+}
+
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	var current strings.Builder
+	runes := []rune(input)
+
+	in_token := false
+	start := 0
+
+	for n := 0; n < len(runes); n++ {
+		r := runes[n]
+		if unicode.IsSpace(r) {
+			if in_token {
+				tokens = append(tokens, token{Text: current.String(), Pos: start, End: n})
+				current.Reset()
+				in_token = false
+			}
+			continue
+		}
+		if !in_token {
+			// A # at the start of an argument comments out the rest of the line
+			if r == '#' {
+				break
+			}
+			in_token = true
+			start = n
+		}
+		switch {
+		case r == '\\':
+			if n+1 >= len(runes) {
+				return nil, &SyntaxError{Input: input, Pos: n, Msg: "nothing left to escape after backslash"}
+			}
+			n++
+			current.WriteRune(runes[n])
+		case r == '\'' || r == '"':
+			quote := r
+			opened := n
+			closed := false
+			for n++; n < len(runes); n++ {
+				if runes[n] == quote {
+					closed = true
+					break
+				}
+				// Inside double quotes a backslash only escapes a double quote or another backslash
+				if quote == '"' && runes[n] == '\\' && n+1 < len(runes) && (runes[n+1] == '"' || runes[n+1] == '\\') {
+					n++
+				}
+				current.WriteRune(runes[n])
+			}
+			if !closed {
+				return nil, &SyntaxError{Input: input, Pos: opened, Width: len(runes) - opened, Msg: fmt.Sprintf("unterminated %c quote", quote)}
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if in_token {
+		tokens = append(tokens, token{Text: current.String(), Pos: start, End: len(runes)})
+	}
+	return tokens, nil
+}
+
+func splitNameArg(arg string) (string, string, bool) {
+	// Split an argument in the format [name]:[value] on its last colon so names can contain colons
+	i := strings.LastIndex(arg, ":")
+	if i < 0 {
+		return arg, "", false
+	}
+	return arg[:i], arg[i+1:], true
+}
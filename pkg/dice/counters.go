@@ -0,0 +1,87 @@
+package dice
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Counter is a number kept on the table next to the dice, like hit points, stress or momentum.
+// It never goes past its bounds
+type Counter struct {
+	Value int
+	Min   *int // nil if the counter has no lower bound
+	Max   *int // nil if the counter has no upper bound
+	// Clocks are shown as segments filled from Min up to Max, like [###---]
+	Clock bool
+}
+
+func NewClock(segments int) (*Counter, error) {
+	// Create a clock with all of its segments empty
+	if segments < 1 {
+		return nil, fmt.Errorf("a clock needs at least one segment")
+	}
+	low, high := 0, segments
+	return &Counter{Min: &low, Max: &high, Clock: true}, nil
+}
+
+func (counter *Counter) Set(n int) int {
+	// Set the counter to n, or the bound it would go past. Returns the value it was set to
+	if counter.Min != nil && n < *counter.Min {
+		n = *counter.Min
+	}
+	if counter.Max != nil && n > *counter.Max {
+		n = *counter.Max
+	}
+	counter.Value = n
+	return n
+}
+
+func (counter *Counter) Add(n int) int {
+	// Add n to the counter, stopping at its bounds. Returns the new value
+	return counter.Set(counter.Value + n)
+}
+
+func (counter *Counter) Full() bool {
+	// Check if the counter is at its upper bound, which for a clock means every segment is filled
+	return counter.Max != nil && counter.Value >= *counter.Max
+}
+
+func (counter *Counter) Copy() *Counter {
+	copied := *counter
+	if counter.Min != nil {
+		low := *counter.Min
+		copied.Min = &low
+	}
+	if counter.Max != nil {
+		high := *counter.Max
+		copied.Max = &high
+	}
+	return &copied
+}
+
+func (counter *Counter) Equal(other *Counter) bool {
+	sameBound := func(a *int, b *int) bool {
+		return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+	}
+	return counter.Value == other.Value && counter.Clock == other.Clock && sameBound(counter.Min, other.Min) && sameBound(counter.Max, other.Max)
+}
+
+func (counter *Counter) String() string {
+	// Show the value and the bounds, or the filled segments of a clock
+	if counter.Clock && counter.Min != nil && counter.Max != nil {
+		filled := counter.Value - *counter.Min
+		segments := *counter.Max - *counter.Min
+		return fmt.Sprintf("[%s%s] %d/%d", strings.Repeat("#", filled), strings.Repeat("-", segments-filled), filled, segments)
+	}
+	var bounds []string
+	if counter.Min != nil {
+		bounds = append(bounds, fmt.Sprintf("min %d", *counter.Min))
+	}
+	if counter.Max != nil {
+		bounds = append(bounds, fmt.Sprintf("max %d", *counter.Max))
+	}
+	if len(bounds) == 0 {
+		return fmt.Sprintf("%d", counter.Value)
+	}
+	return fmt.Sprintf("%d (%s)", counter.Value, strings.Join(bounds, ", "))
+}
@@ -1,15 +1,73 @@
 package dice
 
 import (
+	crypto "crypto/rand"
+	"encoding/binary"
 	"fmt"
 	"math/rand"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// The random numbers used to roll dice. rng_mutex guards both since rolls can come from more than one goroutine
+var rng *rand.Rand
+var seed int64
+var rng_mutex sync.Mutex
+
+// Set by UseCrypto to take new seeds from the operating system instead of the current random numbers
+var crypto_seeds bool
+
 func init() {
-	rand.Seed(time.Now().UnixNano())
+	Seed(time.Now().UnixNano())
+}
+
+func Seed(s int64) {
+	// Start the random numbers used for rolling dice from a seed so the same rolls can be made again
+	rng_mutex.Lock()
+	defer rng_mutex.Unlock()
+	seed = s
+	rng = rand.New(rand.NewSource(s))
+}
+
+func CurrentSeed() int64 {
+	// Return the seed the dice were last seeded with
+	rng_mutex.Lock()
+	defer rng_mutex.Unlock()
+	return seed
+}
+
+func Reseed() int64 {
+	// Seed the dice with a new seed taken from the current random numbers and return it.
+	// Recording the seed before a set of rolls is enough to make those rolls again
+	rng_mutex.Lock()
+	s := rng.Int63()
+	use_crypto := crypto_seeds
+	rng_mutex.Unlock()
+	if use_crypto {
+		var b [8]byte
+		if _, err := crypto.Read(b[:]); err == nil {
+			s = int64(binary.LittleEndian.Uint64(b[:]) >> 1)
+		}
+	}
+	Seed(s)
+	return s
+}
+
+func UseCrypto(on bool) {
+	// Take each new seed from the operating system's random numbers so the next seed can't be worked out
+	// from the last one. The seeds are still recorded so rolls can be made again
+	rng_mutex.Lock()
+	defer rng_mutex.Unlock()
+	crypto_seeds = on
+}
+
+func intn(n int) int {
+	rng_mutex.Lock()
+	defer rng_mutex.Unlock()
+	return rng.Intn(n)
 }
 
 type Die struct {
@@ -18,7 +76,7 @@ type Die struct {
 }
 
 func (die *Die) Roll() {
-	roll := rand.Intn(die.Sides-1) + 1
+	roll := intn(die.Sides) + 1
 	die.Top = roll
 }
 
@@ -75,17 +133,33 @@ func ParseDiceString(pool_string string) (*Pool, error) {
 	return pool, err
 }
 
+// How many previous rolls each pool remembers
+var MaxHistory = 100
+
 type Pool struct {
 	Dice        []*Die
 	Sides       int
 	Description string
+	// The faces of the dice after each time the pool was rolled, oldest first
+	History [][]int
+	// Labels used to pick out groups of pools, kept sorted
+	Tags []string
 }
 
 func (pool *Pool) Roll() {
-	// Roll each die in the pool
+	// Roll each die in the pool and remember the roll
 	for _, die := range pool.Dice {
 		die.Roll()
 	}
+	pool.Record()
+}
+
+func (pool *Pool) Record() {
+	// Add the faces the dice are showing to the history, forgetting the oldest roll once there are more than MaxHistory
+	pool.History = append(pool.History, pool.List())
+	if MaxHistory > 0 && len(pool.History) > MaxHistory {
+		pool.History = append([][]int{}, pool.History[len(pool.History)-MaxHistory:]...)
+	}
 }
 
 func (pool *Pool) List() []int {
@@ -122,38 +196,194 @@ func (pool *Pool) Subtract() error {
 	return err
 }
 
+func (pool *Pool) SetFaces(faces []int, resize bool) error {
+	// Set each die to the face at the same position in faces. If resize is true dice are added or removed
+	// so the pool has one die for each face, otherwise the number of faces has to match the number of dice.
+	// Nothing is changed if any face can't be set
+	if !resize && len(faces) != len(pool.Dice) {
+		return fmt.Errorf("%d faces were given for a pool of %d dice", len(faces), len(pool.Dice))
+	}
+	for n, face := range faces {
+		if face < 1 || face > pool.Sides {
+			return fmt.Errorf("die %d cannot be set to %d, it has %d sides", n, face, pool.Sides)
+		}
+	}
+
+	for len(pool.Dice) < len(faces) {
+		pool.Add()
+	}
+	pool.Dice = pool.Dice[:len(faces)]
+	for n, face := range faces {
+		pool.Dice[n].Top = face
+	}
+	return nil
+}
+
+func (pool *Pool) Copy() *Pool {
+	// Return a new pool with copies of each die so changes to one pool don't change the other
+	dice := make([]*Die, len(pool.Dice))
+	for n, die := range pool.Dice {
+		d := *die
+		dice[n] = &d
+	}
+	history := make([][]int, len(pool.History))
+	for n, roll := range pool.History {
+		history[n] = append([]int{}, roll...)
+	}
+	tags := append([]string{}, pool.Tags...)
+	return &Pool{Dice: dice, Sides: pool.Sides, Description: pool.Description, History: history, Tags: tags}
+}
+
+func (pool *Pool) HasTag(tag string) bool {
+	for _, t := range pool.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func (pool *Pool) Tag(tag string) bool {
+	// Add a tag to the pool. Returns false if the pool already had it
+	if pool.HasTag(tag) {
+		return false
+	}
+	pool.Tags = append(pool.Tags, tag)
+	sort.Strings(pool.Tags)
+	return true
+}
+
+func (pool *Pool) Untag(tag string) bool {
+	// Remove a tag from the pool. Returns false if the pool didn't have it
+	for n, t := range pool.Tags {
+		if t == tag {
+			pool.Tags = append(pool.Tags[:n:n], pool.Tags[n+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// How the faces of a pool can be shown
+const (
+	ShowList    = "list"    // in the order of the dice
+	ShowSorted  = "sorted"  // highest first
+	ShowGrouped = "grouped" // each face once with how many dice show it, highest first, like 6×3, 5×1, 2×2
+	ShowAuto    = "auto"    // grouped if the pool has more than GroupOver dice, otherwise in the order of the dice
+)
+
+// Pools with more dice than this are grouped when they are shown auto
+var GroupOver = 10
+
+func (pool *Pool) Sort(descending bool) {
+	// Put the dice in the pool in order of the faces they show. Dice showing the same face keep their order
+	sort.SliceStable(pool.Dice, func(i, j int) bool {
+		if descending {
+			return pool.Dice[i].Top > pool.Dice[j].Top
+		}
+		return pool.Dice[i].Top < pool.Dice[j].Top
+	})
+}
+
+func (pool *Pool) Sorted() []int {
+	// Return the faces of the dice highest first without changing the order of the dice
+	list := pool.List()
+	sort.Sort(sort.Reverse(sort.IntSlice(list)))
+	return list
+}
+
+func (pool *Pool) Grouped() string {
+	// Return each face shown once with how many dice show it, highest first, like 6×3, 5×1, 2×2
+	list := pool.Sorted()
+	var groups []string
+	for n := 0; n < len(list); {
+		count := 1
+		for n+count < len(list) && list[n+count] == list[n] {
+			count++
+		}
+		groups = append(groups, fmt.Sprintf("%d×%d", list[n], count))
+		n += count
+	}
+	return strings.Join(groups, ", ")
+}
+
+func (pool *Pool) resolveShow(show string) string {
+	// Decide whether a pool shown auto is grouped or listed
+	if show != ShowAuto {
+		return show
+	}
+	if len(pool.Dice) > GroupOver {
+		return ShowGrouped
+	}
+	return ShowList
+}
+
+func (pool *Pool) Faces(show string) string {
+	// Return the faces of the dice the way show says to. Anything that isn't sorted, grouped or auto is a list
+	switch pool.resolveShow(show) {
+	case ShowSorted:
+		return fmt.Sprint(pool.Sorted())
+	case ShowGrouped:
+		return pool.Grouped()
+	}
+	return fmt.Sprint(pool.List())
+}
+
 func (pool *Pool) Describe() string {
 	// Return a human readable description of the dice in the string
-	list_dice := pool.List()
-	first_dice := list_dice[0 : len(list_dice)-1]
+	return pool.DescribeAs(ShowList)
+}
+
+func (pool *Pool) DescribeAs(show string) string {
+	// Return a human readable description of the dice with the faces shown the way show says to
+	var faces string
+	show = pool.resolveShow(show)
+	switch list_dice := pool.List(); {
+	case show == ShowGrouped:
+		faces = pool.Grouped()
+	case len(list_dice) == 1:
+		faces = fmt.Sprintf("%d", list_dice[0])
+	case len(list_dice) > 1:
+		if show == ShowSorted {
+			list_dice = pool.Sorted()
+		}
+		first_dice := list_dice[0 : len(list_dice)-1]
 
-	var first_str string
-	for _, i := range first_dice {
-		s := fmt.Sprintf("%d, ", i)
-		first_str += s
+		var first_str string
+		for _, i := range first_dice {
+			s := fmt.Sprintf("%d, ", i)
+			first_str += s
+		}
+		faces = fmt.Sprintf("%sand %d", first_str, list_dice[len(list_dice)-1])
 	}
 
-	last_die := list_dice[len(list_dice)-1]
-	desc := fmt.Sprintf("A pool of %d d%ds. The dice are facing %sand %d.", len(pool.Dice), pool.Sides, first_str, last_die)
+	desc := fmt.Sprintf("A pool of %d d%ds.", len(pool.Dice), pool.Sides)
+	if len(pool.Dice) > 0 {
+		desc += fmt.Sprintf(" The dice are facing %s.", faces)
+	}
 
 	// If the pool has a description add it onto the end of the normal description
 	if pool.Description != "" {
 		desc = desc + " " + pool.Description
 	}
+	if len(pool.Tags) > 0 {
+		desc = desc + fmt.Sprintf(" Tags: %s.", strings.Join(pool.Tags, ", "))
+	}
 	return desc
 }
 
 func CreateTable(pool_list []*Pool, names []string) (Table, error) {
 	var err error
 	pools := make(map[string]*Pool)
+	counters := make(map[string]*Counter)
 	if len(pool_list) != len(names) {
 		err = fmt.Errorf("the number of pools and the number of names do not match up")
-		return Table{Pools: pools, Name: ""}, err
+		return Table{Pools: pools, Name: "", Counters: counters}, err
 	} else {
 		for d := 0; d < len(pool_list); d++ {
 			pools[names[d]] = pool_list[d]
 		}
-		return Table{Pools: pools, Name: ""}, err
+		return Table{Pools: pools, Name: "", Counters: counters}, err
 	}
 }
 
@@ -181,6 +411,10 @@ func ParseTableString(pool_list []string, names []string) (Table, error) {
 type Table struct {
 	Pools map[string]*Pool
 	Name  string
+	// Numbers kept next to the dice, like hit points or clocks. nil until the first one is added
+	Counters map[string]*Counter
+	// The turn order of a fight, nil if there isn't one
+	Initiative *Initiative
 }
 
 func (table *Table) Roll() {
@@ -208,7 +442,61 @@ func (table *Table) Remove(name string) error {
 	return err
 }
 
+func (table *Table) Rename(name string, new_name string) error {
+	// Give a pool a new name. Returns an error if there is no pool with the name or the new name is taken
+	pool, ok := table.Pools[name]
+	if !ok {
+		return fmt.Errorf("%s is not the name of a pool in this table", name)
+	}
+	if _, ok := table.Pools[new_name]; ok {
+		return fmt.Errorf("there is already a pool named %s in this table", new_name)
+	}
+	delete(table.Pools, name)
+	table.Pools[new_name] = pool
+	return nil
+}
+
+func (table *Table) Tagged(tag string) []string {
+	// Return the names of the pools with a tag, sorted
+	var names []string
+	for name, pool := range table.Pools {
+		if pool.HasTag(tag) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (table *Table) Add(name string, size int, sides int) {
 	// Add a pool to a table
 	table.Pools[name] = CreatePool(size, sides)
 }
+
+func (table *Table) Copy() Table {
+	// Return a new table with copies of each pool so changes to one table don't change the other
+	pools := make(map[string]*Pool)
+	for name, pool := range table.Pools {
+		pools[name] = pool.Copy()
+	}
+	var counters map[string]*Counter
+	if table.Counters != nil {
+		counters = make(map[string]*Counter)
+		for name, counter := range table.Counters {
+			counters[name] = counter.Copy()
+		}
+	}
+	copied := Table{Pools: pools, Name: table.Name, Counters: counters}
+	if table.Initiative != nil {
+		copied.Initiative = table.Initiative.Copy()
+	}
+	return copied
+}
+
+func (table *Table) AddCounter(name string, counter *Counter) {
+	// Add a counter to the table, replacing any counter with the same name
+	if table.Counters == nil {
+		table.Counters = make(map[string]*Counter)
+	}
+	table.Counters[name] = counter
+}
@@ -0,0 +1,230 @@
+package dice
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The most dice one term of an expression can roll
+const MaxExpressionDice = 10000
+
+// A Term is one part of a dice expression, either a number of dice like 4d6kh3 or a constant like 4
+type Term struct {
+	Negative bool
+	Count    int // how many dice to roll, 0 for a constant
+	Sides    int
+	Value    int  // the value of a constant
+	Keep     int  // how many of the dice to keep, 0 keeps all of them
+	Lowest   bool // keep the lowest dice instead of the highest
+}
+
+type Expression struct {
+	Text  string
+	Terms []Term
+}
+
+var dice_term = regexp.MustCompile(`^(\d*)d(\d+|%)(?:(kh|kl|k|dh|dl|d)(\d*))?`)
+var constant_term = regexp.MustCompile(`^\d+`)
+var operator_spaces = regexp.MustCompile(`\s*([+-])\s*`)
+
+func ParseExpression(expression string) (*Expression, error) {
+	// Read an expression like 1d20+4, 2d6 + 1d8 - 1 or 4d6kh3 and return its terms.
+	// XdY rolls X dice with Y sides, d% is a d100, and X can be left out to roll one die.
+	// khN/klN keep the highest or lowest N dice and dhN/dlN drop them, N can be left out to keep or drop one
+	// Spaces are allowed around + and - but not inside a term
+	text := strings.ToLower(operator_spaces.ReplaceAllString(strings.TrimSpace(expression), "$1"))
+	if text == "" {
+		return nil, fmt.Errorf("dice expressions need at least one term like 1d20 or 2d6+3")
+	}
+	if strings.ContainsAny(text, " \t\n") {
+		return nil, fmt.Errorf("%q has spaces inside a term", expression)
+	}
+
+	parsed := &Expression{Text: text}
+	rest := text
+	for len(rest) > 0 {
+		var term Term
+		position := len(text) - len(rest)
+
+		// Every term but the first has to start with a sign
+		if rest[0] == '+' || rest[0] == '-' {
+			term.Negative = rest[0] == '-'
+			rest = rest[1:]
+		} else if len(parsed.Terms) > 0 {
+			return nil, fmt.Errorf("expected + or - at %q in %s", rest, text)
+		}
+
+		if match := dice_term.FindStringSubmatch(rest); match != nil {
+			err := term.parseDice(match)
+			if err != nil {
+				return nil, fmt.Errorf("%v in %s", err, text)
+			}
+			rest = rest[len(match[0]):]
+		} else if match := constant_term.FindString(rest); match != "" {
+			value, err := strconv.Atoi(match)
+			if err != nil {
+				return nil, fmt.Errorf("%s is too big in %s", match, text)
+			}
+			term.Value = value
+			rest = rest[len(match):]
+		} else {
+			return nil, fmt.Errorf("%q at position %d of %s is not dice like XdY or a number", rest, position+1, text)
+		}
+		parsed.Terms = append(parsed.Terms, term)
+	}
+	return parsed, nil
+}
+
+func (term *Term) parseDice(match []string) error {
+	var err error
+	term.Count = 1
+	if match[1] != "" {
+		term.Count, err = strconv.Atoi(match[1])
+		if err != nil || term.Count < 1 || term.Count > MaxExpressionDice {
+			return fmt.Errorf("the number of dice in %s has to be between 1 and %d", match[0], MaxExpressionDice)
+		}
+	}
+	if match[2] == "%" {
+		term.Sides = 100
+	} else {
+		term.Sides, err = strconv.Atoi(match[2])
+		if err != nil || term.Sides < 1 {
+			return fmt.Errorf("the dice in %s need at least one side", match[0])
+		}
+	}
+
+	if match[3] == "" {
+		return nil
+	}
+	n := 1
+	if match[4] != "" {
+		n, err = strconv.Atoi(match[4])
+		if err != nil || n < 0 || n > term.Count {
+			return fmt.Errorf("cannot keep or drop %s of the %d dice in %s", match[4], term.Count, match[0])
+		}
+	}
+	switch match[3] {
+	case "k", "kh":
+		term.Keep = n
+	case "kl":
+		term.Keep, term.Lowest = n, true
+	case "d", "dl":
+		term.Keep = term.Count - n
+	case "dh":
+		term.Keep, term.Lowest = term.Count-n, true
+	}
+	if term.Keep == 0 {
+		return fmt.Errorf("%s doesn't keep any dice", match[0])
+	}
+	return nil
+}
+
+func (term Term) String() string {
+	if term.Count == 0 {
+		return strconv.Itoa(term.Value)
+	}
+	str := fmt.Sprintf("%dd%d", term.Count, term.Sides)
+	if term.Keep > 0 && term.Keep < term.Count {
+		if term.Lowest {
+			str += fmt.Sprintf("kl%d", term.Keep)
+		} else {
+			str += fmt.Sprintf("kh%d", term.Keep)
+		}
+	}
+	return str
+}
+
+// The result of rolling one term. Kept is false for dice that were dropped
+type TermResult struct {
+	Term  Term
+	Faces []int
+	Kept  []bool
+	Total int
+}
+
+type ExpressionResult struct {
+	Expression *Expression
+	Terms      []TermResult
+	Total      int
+}
+
+func (expression *Expression) Roll() ExpressionResult {
+	// Roll every term of the expression and add them up
+	result := ExpressionResult{Expression: expression}
+	for _, term := range expression.Terms {
+		term_result := TermResult{Term: term}
+		if term.Count == 0 {
+			term_result.Total = term.Value
+		} else {
+			pool := CreatePool(term.Count, term.Sides)
+			pool.Roll()
+			term_result.Faces = pool.List()
+			term_result.Kept = keep(term_result.Faces, term.Keep, term.Lowest)
+			for n, face := range term_result.Faces {
+				if term_result.Kept[n] {
+					term_result.Total += face
+				}
+			}
+		}
+		if term.Negative {
+			term_result.Total = -term_result.Total
+		}
+		result.Total += term_result.Total
+		result.Terms = append(result.Terms, term_result)
+	}
+	return result
+}
+
+func keep(faces []int, n int, lowest bool) []bool {
+	// Mark which faces are kept when keeping the n highest, or lowest, of them. Ties keep the earlier die
+	kept := make([]bool, len(faces))
+	if n <= 0 || n >= len(faces) {
+		for i := range kept {
+			kept[i] = true
+		}
+		return kept
+	}
+	for count := 0; count < n; count++ {
+		best := -1
+		for i, face := range faces {
+			if kept[i] {
+				continue
+			}
+			if best < 0 || (!lowest && face > faces[best]) || (lowest && face < faces[best]) {
+				best = i
+			}
+		}
+		kept[best] = true
+	}
+	return kept
+}
+
+func (result ExpressionResult) String() string {
+	// Describe each term and the total, like 2d6+1d8+3: [4 2] + [7] + 3 = 16. Dropped dice are in parentheses
+	var parts strings.Builder
+	for n, term := range result.Terms {
+		if n > 0 || term.Term.Negative {
+			if term.Term.Negative {
+				parts.WriteString(" - ")
+			} else {
+				parts.WriteString(" + ")
+			}
+		}
+		if term.Term.Count == 0 {
+			parts.WriteString(strconv.Itoa(term.Term.Value))
+			continue
+		}
+		faces := make([]string, len(term.Faces))
+		for i, face := range term.Faces {
+			if term.Kept[i] {
+				faces[i] = strconv.Itoa(face)
+			} else {
+				faces[i] = fmt.Sprintf("(%d)", face)
+			}
+		}
+		parts.WriteString("[" + strings.Join(faces, " ") + "]")
+	}
+	return fmt.Sprintf("%s: %s = %d", result.Expression.Text, strings.TrimPrefix(parts.String(), " "), result.Total)
+}
@@ -0,0 +1,199 @@
+package dice
+
+import (
+	"fmt"
+	"sort"
+)
+
+// The die rolled for initiative
+const InitiativeSides = 20
+
+// A Combatant is one place in the initiative order
+type Combatant struct {
+	Name     string
+	Modifier int
+	Roll     int
+	// Rolled to settle ties between combatants with the same total and modifier
+	TieBreak int
+}
+
+func (combatant Combatant) Total() int {
+	return combatant.Roll + combatant.Modifier
+}
+
+// Initiative is the order combatants take their turns in. Turn is the position of the combatant whose turn it is
+// and Round counts from 1
+type Initiative struct {
+	Combatants []Combatant
+	Turn       int
+	Round      int
+}
+
+func rollCombatant(name string, modifier int) Combatant {
+	die := Die{Sides: InitiativeSides}
+	die.Roll()
+	return Combatant{Name: name, Modifier: modifier, Roll: die.Top, TieBreak: intn(1000000)}
+}
+
+func RollInitiative(names []string, modifiers []int) (*Initiative, error) {
+	// Roll initiative for each combatant and put them in order, highest total first.
+	// Ties go to the higher modifier and then to the higher tie break roll. The first round starts at the top
+	if len(names) != len(modifiers) {
+		return nil, fmt.Errorf("the number of combatants and the number of modifiers do not match up")
+	}
+	order := &Initiative{Round: 1}
+	for n, name := range names {
+		if order.find(name) >= 0 {
+			return nil, fmt.Errorf("%s is in the initiative order more than once", name)
+		}
+		order.Combatants = append(order.Combatants, rollCombatant(name, modifiers[n]))
+	}
+	order.sort()
+	return order, nil
+}
+
+func goesBefore(a Combatant, b Combatant) bool {
+	if a.Total() != b.Total() {
+		return a.Total() > b.Total()
+	}
+	if a.Modifier != b.Modifier {
+		return a.Modifier > b.Modifier
+	}
+	return a.TieBreak > b.TieBreak
+}
+
+func (order *Initiative) sort() {
+	sort.SliceStable(order.Combatants, func(i, j int) bool {
+		return goesBefore(order.Combatants[i], order.Combatants[j])
+	})
+}
+
+func (order *Initiative) find(name string) int {
+	for n, combatant := range order.Combatants {
+		if combatant.Name == name {
+			return n
+		}
+	}
+	return -1
+}
+
+func (order *Initiative) Current() *Combatant {
+	// Return the combatant whose turn it is, or nil if there are no combatants
+	if order.Turn < 0 || order.Turn >= len(order.Combatants) {
+		return nil
+	}
+	return &order.Combatants[order.Turn]
+}
+
+func (order *Initiative) Add(name string, modifier int) (Combatant, error) {
+	// Roll initiative for a combatant joining partway through and put them before the first combatant they beat.
+	// The rest of the order is left alone so delays are kept, and it stays the turn of whoever's turn it was
+	if order.find(name) >= 0 {
+		return Combatant{}, fmt.Errorf("%s is already in the initiative order", name)
+	}
+	combatant := rollCombatant(name, modifier)
+	place := len(order.Combatants)
+	for n, other := range order.Combatants {
+		if goesBefore(combatant, other) {
+			place = n
+			break
+		}
+	}
+	order.Combatants = append(order.Combatants, Combatant{})
+	copy(order.Combatants[place+1:], order.Combatants[place:])
+	order.Combatants[place] = combatant
+	if place <= order.Turn && len(order.Combatants) > 1 {
+		order.Turn++
+	}
+	return combatant, nil
+}
+
+func (order *Initiative) Remove(name string) error {
+	// Take a combatant out of the order. If it was their turn it becomes the turn of the next combatant
+	n := order.find(name)
+	if n < 0 {
+		return fmt.Errorf("%s is not in the initiative order", name)
+	}
+	order.Combatants = append(order.Combatants[:n:n], order.Combatants[n+1:]...)
+	if n < order.Turn {
+		order.Turn--
+	}
+	if order.Turn >= len(order.Combatants) {
+		order.Turn = 0
+		order.Round++
+	}
+	return nil
+}
+
+func (order *Initiative) Next() {
+	// Move to the next combatant's turn, starting a new round after the last one
+	if len(order.Combatants) == 0 {
+		return
+	}
+	order.Turn++
+	if order.Turn >= len(order.Combatants) {
+		order.Turn = 0
+		order.Round++
+	}
+}
+
+func (order *Initiative) Prev() {
+	// Move back to the previous combatant's turn, going back a round from the first one. Never goes before the first turn
+	if len(order.Combatants) == 0 || (order.Turn == 0 && order.Round <= 1) {
+		return
+	}
+	order.Turn--
+	if order.Turn < 0 {
+		order.Turn = len(order.Combatants) - 1
+		order.Round--
+	}
+}
+
+func (order *Initiative) Delay(after string) error {
+	// Move the combatant whose turn it is to just after another combatant, or after the next one if after is "".
+	// Their turn ends and it becomes the turn of whoever is now in their place
+	current := order.Current()
+	if current == nil {
+		return fmt.Errorf("there are no combatants in the initiative order")
+	}
+	from := order.Turn
+	to := from + 1
+	if after != "" {
+		to = order.find(after)
+		if to < 0 {
+			return fmt.Errorf("%s is not in the initiative order", after)
+		}
+	}
+	if to == from {
+		return fmt.Errorf("%s cannot delay until after their own turn", current.Name)
+	}
+	if to < from {
+		return fmt.Errorf("%s has already had their turn this round", after)
+	}
+	if to >= len(order.Combatants) {
+		return fmt.Errorf("%s is the last in the order and cannot delay", current.Name)
+	}
+
+	delayed := *current
+	copy(order.Combatants[from:to], order.Combatants[from+1:to+1])
+	order.Combatants[to] = delayed
+	return nil
+}
+
+func (order *Initiative) Copy() *Initiative {
+	copied := *order
+	copied.Combatants = append([]Combatant{}, order.Combatants...)
+	return &copied
+}
+
+func (order *Initiative) Equal(other *Initiative) bool {
+	if order.Turn != other.Turn || order.Round != other.Round || len(order.Combatants) != len(other.Combatants) {
+		return false
+	}
+	for n := range order.Combatants {
+		if order.Combatants[n] != other.Combatants[n] {
+			return false
+		}
+	}
+	return true
+}
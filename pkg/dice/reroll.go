@@ -0,0 +1,141 @@
+package dice
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// How many times one die can be rerolled by a recursive reroll before giving up
+const MaxRerolls = 100
+
+// A Condition picks out dice by the face they show, like <3, >=5 or 1,2
+type Condition struct {
+	Op     string // one of = < <= > >=
+	Values []int  // only = can have more than one value
+}
+
+func ParseCondition(text string) (Condition, error) {
+	// Read a condition. A list of faces without an operator is the same as =
+	var condition Condition
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(text, op) {
+			condition.Op = op
+			text = text[len(op):]
+			break
+		}
+	}
+	if condition.Op == "" {
+		condition.Op = "="
+	}
+
+	for _, value := range strings.Split(text, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return condition, fmt.Errorf("%s is not a face to compare dice to", value)
+		}
+		condition.Values = append(condition.Values, n)
+	}
+	if condition.Op != "=" && len(condition.Values) != 1 {
+		return condition, fmt.Errorf("%s can only compare dice to one face", condition.Op)
+	}
+	return condition, nil
+}
+
+func (condition Condition) Match(face int) bool {
+	switch condition.Op {
+	case "<":
+		return face < condition.Values[0]
+	case "<=":
+		return face <= condition.Values[0]
+	case ">":
+		return face > condition.Values[0]
+	case ">=":
+		return face >= condition.Values[0]
+	}
+	for _, value := range condition.Values {
+		if face == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (condition Condition) String() string {
+	values := make([]string, len(condition.Values))
+	for n, value := range condition.Values {
+		values[n] = strconv.Itoa(value)
+	}
+	return condition.Op + strings.Join(values, ",")
+}
+
+// A Reroll is what happened to one die that was rerolled. Faces has every face it showed, starting with the one it was
+// rerolled from, so a die rerolled recursively has more than two
+type Reroll struct {
+	Index int
+	Faces []int
+}
+
+func (reroll Reroll) From() int {
+	return reroll.Faces[0]
+}
+
+func (reroll Reroll) To() int {
+	return reroll.Faces[len(reroll.Faces)-1]
+}
+
+func (pool *Pool) RerollDice(indexes []int) ([]Reroll, error) {
+	// Reroll the dice at each position once. Returns an error without rolling anything if a position isn't in the pool.
+	// Rerolls are not added to the history of the pool
+	for _, index := range indexes {
+		if index < 0 || index >= len(pool.Dice) {
+			return nil, fmt.Errorf("there is no die %d in a pool of %d dice", index, len(pool.Dice))
+		}
+	}
+
+	var rerolls []Reroll
+	rerolled := make(map[int]bool)
+	for _, index := range indexes {
+		if rerolled[index] {
+			continue
+		}
+		rerolled[index] = true
+		die := pool.Dice[index]
+		from := die.Top
+		die.Roll()
+		rerolls = append(rerolls, Reroll{Index: index, Faces: []int{from, die.Top}})
+	}
+	return rerolls, nil
+}
+
+func (pool *Pool) RerollWhere(condition Condition, recursive bool) ([]Reroll, error) {
+	// Reroll each die showing a face that matches the condition. If recursive is true a die is rerolled until
+	// it no longer matches. Returns an error without rolling anything if every face would match, since the dice
+	// could never stop being rerolled. Rerolls are not added to the history of the pool
+	if recursive {
+		always := true
+		for face := 1; face <= pool.Sides && always; face++ {
+			always = condition.Match(face)
+		}
+		if always {
+			return nil, fmt.Errorf("every face of a d%d is %s, the dice would be rerolled forever", pool.Sides, condition)
+		}
+	}
+
+	var rerolls []Reroll
+	for index, die := range pool.Dice {
+		if !condition.Match(die.Top) {
+			continue
+		}
+		reroll := Reroll{Index: index, Faces: []int{die.Top}}
+		for {
+			die.Roll()
+			reroll.Faces = append(reroll.Faces, die.Top)
+			if !recursive || !condition.Match(die.Top) || len(reroll.Faces) > MaxRerolls {
+				break
+			}
+		}
+		rerolls = append(rerolls, reroll)
+	}
+	return rerolls, nil
+}
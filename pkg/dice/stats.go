@@ -0,0 +1,138 @@
+package dice
+
+import (
+	"math"
+)
+
+type PoolStats struct {
+	Sides int
+	Rolls int // how many times the pool was rolled
+	Dice  int // how many dice were rolled over all of the rolls
+
+	Mean     float64 // the average face rolled
+	Expected float64 // the average face fair dice would roll
+	Counts   []int   // how many times each face was rolled. Counts[0] is the number of ones
+
+	TotalMean     float64 // the average total of a roll
+	TotalExpected float64 // the average total fair dice would roll, counting the dice in each roll
+
+	// The most rolls in a row that totalled above, or below, what fair dice would average
+	HighStreak int
+	LowStreak  int
+
+	// How far the counts of each face are from what fair dice would roll, and the chance of fair dice
+	// being at least that far off. A small PValue means the dice are rolling unusually
+	ChiSquare float64
+	PValue    float64
+}
+
+func (pool *Pool) Stats() PoolStats {
+	// Work out the statistics of the rolls in the pool's history
+	return HistoryStats(pool.History, pool.Sides)
+}
+
+func HistoryStats(history [][]int, sides int) PoolStats {
+	stats := PoolStats{Sides: sides, Rolls: len(history), Expected: float64(sides+1) / 2, PValue: 1}
+	if sides < 1 {
+		return stats
+	}
+	stats.Counts = make([]int, sides)
+
+	sum := 0
+	high, low := 0, 0
+	for _, roll := range history {
+		total := 0
+		for _, face := range roll {
+			if face >= 1 && face <= sides {
+				stats.Counts[face-1]++
+			}
+			total += face
+		}
+		sum += total
+		stats.Dice += len(roll)
+
+		// Compare twice the total to avoid fractions when the expected total ends in a half
+		switch twice := 2 * total; {
+		case twice > len(roll)*(sides+1):
+			high, low = high+1, 0
+		case twice < len(roll)*(sides+1):
+			high, low = 0, low+1
+		default:
+			high, low = 0, 0
+		}
+		if high > stats.HighStreak {
+			stats.HighStreak = high
+		}
+		if low > stats.LowStreak {
+			stats.LowStreak = low
+		}
+	}
+	if stats.Rolls == 0 || stats.Dice == 0 {
+		return stats
+	}
+
+	stats.Mean = float64(sum) / float64(stats.Dice)
+	stats.TotalMean = float64(sum) / float64(stats.Rolls)
+	stats.TotalExpected = stats.Expected * float64(stats.Dice) / float64(stats.Rolls)
+
+	if sides > 1 {
+		expected_count := float64(stats.Dice) / float64(sides)
+		for _, count := range stats.Counts {
+			diff := float64(count) - expected_count
+			stats.ChiSquare += diff * diff / expected_count
+		}
+		stats.PValue = chiSquareSurvival(stats.ChiSquare, sides-1)
+	}
+	return stats
+}
+
+func chiSquareSurvival(x float64, degrees int) float64 {
+	// The chance of a chi-square value at least x with the given degrees of freedom.
+	// This is the regularized upper incomplete gamma function Q(degrees/2, x/2)
+	if x <= 0 {
+		return 1
+	}
+	a := float64(degrees) / 2
+	x = x / 2
+	lgamma, _ := math.Lgamma(a)
+
+	if x < a+1 {
+		// Series for the lower function, then take it from one
+		sum := 1 / a
+		term := sum
+		for n := 1; n < 1000; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*1e-12 {
+				break
+			}
+		}
+		return math.Max(0, 1-sum*math.Exp(-x+a*math.Log(x)-lgamma))
+	}
+
+	// Continued fraction for the upper function
+	tiny := 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < 1000; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-12 {
+			break
+		}
+	}
+	return math.Exp(-x+a*math.Log(x)-lgamma) * h
+}
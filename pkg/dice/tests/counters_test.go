@@ -0,0 +1,48 @@
+package dice_test
+
+import (
+	"dicetable/pkg/dice"
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	// A counter should stop at its bounds and copies should not share them
+	low, high := 0, 10
+	counter := dice.Counter{Value: 5, Min: &low, Max: &high}
+	if counter.Add(8) != 10 || counter.Add(-25) != 0 {
+		t.Errorf("The counter should stop at 10 and 0, instead it is %d", counter.Value)
+	}
+	if counter.String() != "0 (min 0, max 10)" {
+		t.Errorf("The counter should show its bounds, instead it shows %s", counter.String())
+	}
+
+	copied := counter.Copy()
+	*copied.Max = 20
+	if *counter.Max != 10 || counter.Equal(copied) {
+		t.Errorf("Changing the bounds of a copy should not change the original")
+	}
+
+	unbounded := dice.Counter{}
+	if unbounded.Add(-3) != -3 || unbounded.String() != "-3" {
+		t.Errorf("A counter without bounds can go anywhere, instead it is %s", unbounded.String())
+	}
+}
+
+func TestClock(t *testing.T) {
+	// A clock should fill one segment at a time and say when it is full
+	clock, err := dice.NewClock(4)
+	if err != nil {
+		t.Fatalf("NewClock returned an error: %v", err)
+	}
+	clock.Add(3)
+	if clock.String() != "[###-] 3/4" || clock.Full() {
+		t.Errorf("The clock should have 3 of 4 segments filled, instead it is %s", clock.String())
+	}
+	clock.Add(3)
+	if clock.String() != "[####] 4/4" || !clock.Full() {
+		t.Errorf("The clock should be full, instead it is %s", clock.String())
+	}
+	if _, err := dice.NewClock(0); err == nil {
+		t.Errorf("A clock without segments should return an error")
+	}
+}
@@ -2,6 +2,7 @@ package dice_test
 
 import (
 	"dicetable/pkg/dice"
+	"reflect"
 	"testing"
 )
 
@@ -33,6 +34,31 @@ func TestRollDie(t *testing.T) {
 	}
 }
 
+func TestRollDieRange(t *testing.T) {
+	// Dice used to roll from 1 to one less than their sides, so the top face never came up and a d1 panicked
+	dice.Seed(1)
+	one := dice.Die{Sides: 1, Top: 1}
+	for n := 0; n < 10; n++ {
+		one.Roll()
+		if one.Top != 1 {
+			t.Fatalf("A d1 rolled %d", one.Top)
+		}
+	}
+
+	die := dice.Die{Sides: 6, Top: 1}
+	seen := make(map[int]bool)
+	for n := 0; n < 600; n++ {
+		die.Roll()
+		if die.Top < 1 || die.Top > 6 {
+			t.Fatalf("A d6 rolled %d", die.Top)
+		}
+		seen[die.Top] = true
+	}
+	if len(seen) != 6 || !seen[6] {
+		t.Errorf("600 rolls of a d6 should show every face including 6, instead showed %v", seen)
+	}
+}
+
 func TestSetDie(t *testing.T) {
 	// Test to see if the Set method successfully sets the die to a specific side and will throw an error if trying to set it to a side greater than it's Sides
 	die := dice.Die{Sides: 6, Top: 1}
@@ -240,6 +266,24 @@ func TestSubtractPool(t *testing.T) {
 	}
 }
 
+func TestCopyPool(t *testing.T) {
+	// The Copy method should return a pool with the same dice that can be changed without changing the original
+	pool := dice.CreatePool(3, 6)
+	pool.Description = "Strength"
+	pool.Dice[0].Set(4)
+	copied := pool.Copy()
+
+	if copied.Sides != 6 || copied.Description != "Strength" || len(copied.Dice) != 3 || copied.Dice[0].Top != 4 {
+		t.Errorf("The copied pool should match the original. Instead it is a pool of %d d%ds facing %d", len(copied.Dice), copied.Sides, copied.List())
+	}
+
+	copied.Dice[0].Set(2)
+	copied.Add()
+	if pool.Dice[0].Top != 4 || len(pool.Dice) != 3 {
+		t.Errorf("Changing the copy changed the original pool. It is now facing %d", pool.List())
+	}
+}
+
 func TestDescribePool(t *testing.T) {
 	// The Description method should return a string that describes the dice in the pool
 	pool := dice.CreatePool(3, 6)
@@ -408,3 +452,160 @@ func TestAddTable(t *testing.T) {
 	}
 
 }
+
+func TestCopyTable(t *testing.T) {
+	// The Copy method should return a table whose pools can be changed without changing the original
+	pools := []*dice.Pool{dice.CreatePool(2, 6), dice.CreatePool(3, 10)}
+	names := []string{"d6s", "d10s"}
+	table, _ := dice.CreateTable(pools, names)
+	table.Name = "MyTable"
+	copied := table.Copy()
+
+	if copied.Name != "MyTable" || len(copied.Pools) != 2 {
+		t.Errorf("The copied table should have the same name and pools. Instead it is %s with %d pools", copied.Name, len(copied.Pools))
+	}
+
+	copied.Remove("d6s")
+	copied.Pools["d10s"].Add()
+	if _, ok := table.Pools["d6s"]; !ok || len(table.Pools["d10s"].Dice) != 3 {
+		t.Errorf("Changing the copy changed the original table")
+	}
+}
+
+func TestSeed(t *testing.T) {
+	// Seeding the dice with the same seed should make the same rolls
+	pool := dice.CreatePool(10, 20)
+	dice.Seed(42)
+	pool.Roll()
+	first := pool.List()
+
+	dice.Seed(42)
+	pool.Roll()
+	second := pool.List()
+	for n := range first {
+		if first[n] != second[n] {
+			t.Errorf("Rolling with the same seed made different rolls: %d and %d", first, second)
+			break
+		}
+	}
+
+	// Reseed should return the seed it used
+	seed := dice.Reseed()
+	if dice.CurrentSeed() != seed {
+		t.Errorf("CurrentSeed returned %d but Reseed returned %d", dice.CurrentSeed(), seed)
+	}
+}
+
+func TestTags(t *testing.T) {
+	// Tags should be kept sorted, only added once, and picked out by the table
+	pools := []*dice.Pool{dice.CreatePool(2, 6), dice.CreatePool(3, 10), dice.CreatePool(1, 20)}
+	table, _ := dice.CreateTable(pools, []string{"sword", "fireball", "luck"})
+	table.Pools["sword"].Tag("combat")
+	table.Pools["fireball"].Tag("magic")
+	table.Pools["fireball"].Tag("combat")
+	if table.Pools["fireball"].Tag("combat") {
+		t.Errorf("Tag should return false when the pool already has the tag")
+	}
+	if tags := table.Pools["fireball"].Tags; len(tags) != 2 || tags[0] != "combat" || tags[1] != "magic" {
+		t.Errorf("The tags should be [combat magic], instead they are %s", tags)
+	}
+	if tagged := table.Tagged("combat"); len(tagged) != 2 || tagged[0] != "fireball" || tagged[1] != "sword" {
+		t.Errorf("Tagged should return fireball and sword, instead it returned %s", tagged)
+	}
+
+	copied := table.Pools["fireball"].Copy()
+	copied.Untag("magic")
+	if !table.Pools["fireball"].HasTag("magic") || copied.HasTag("magic") {
+		t.Errorf("Untagging a copy should not change the original")
+	}
+}
+
+func TestRename(t *testing.T) {
+	// Renaming should move the pool to the new name and refuse names that are taken
+	pools := []*dice.Pool{dice.CreatePool(2, 6), dice.CreatePool(3, 10)}
+	table, _ := dice.CreateTable(pools, []string{"d6s", "d10s"})
+	if err := table.Rename("d6s", "d10s"); err == nil {
+		t.Errorf("Renaming to a name that is taken should return an error")
+	}
+	if err := table.Rename("d6s", "sixes"); err != nil || table.Pools["sixes"] != pools[0] {
+		t.Errorf("d6s should have been renamed to sixes, got %v", err)
+	}
+	if _, ok := table.Pools["d6s"]; ok {
+		t.Errorf("The old name should no longer be on the table")
+	}
+}
+
+func TestSetFaces(t *testing.T) {
+	// SetFaces should set each die in order and only change the size of the pool when asked
+	pool := dice.CreatePool(3, 6)
+	if err := pool.SetFaces([]int{2, 4, 6}, false); err != nil || pool.Dice[2].Top != 6 {
+		t.Errorf("The pool should be facing 2, 4 and 6, instead it is %d with error %v", pool.List(), err)
+	}
+	if err := pool.SetFaces([]int{1, 1}, false); err == nil || len(pool.Dice) != 3 {
+		t.Errorf("Setting the wrong number of faces without resizing should fail")
+	}
+	if err := pool.SetFaces([]int{1, 7, 1}, false); err == nil || pool.Dice[0].Top != 2 {
+		t.Errorf("A face bigger than the dice should fail without changing the pool")
+	}
+	if err := pool.SetFaces([]int{5}, true); err != nil || len(pool.Dice) != 1 || pool.Dice[0].Top != 5 {
+		t.Errorf("Resizing should leave one die facing 5, instead the pool is %d", pool.List())
+	}
+	if err := pool.SetFaces([]int{5, 3, 1, 2}, true); err != nil || len(pool.Dice) != 4 || pool.Dice[3].Sides != 6 {
+		t.Errorf("Resizing should add dice with the same sides, instead the pool is %d", pool.List())
+	}
+}
+
+func TestSortPool(t *testing.T) {
+	// Sort should reorder the dice while Sorted and Grouped leave them where they are
+	pool := dice.CreatePool(6, 6)
+	pool.SetFaces([]int{2, 6, 5, 6, 2, 6}, false)
+
+	if sorted := pool.Sorted(); !reflect.DeepEqual(sorted, []int{6, 6, 6, 5, 2, 2}) {
+		t.Errorf("Sorted should return the faces highest first, instead it returned %d", sorted)
+	}
+	if grouped := pool.Grouped(); grouped != "6×3, 5×1, 2×2" {
+		t.Errorf("Grouped should return 6×3, 5×1, 2×2, instead it returned %s", grouped)
+	}
+	if !reflect.DeepEqual(pool.List(), []int{2, 6, 5, 6, 2, 6}) {
+		t.Errorf("Sorted and Grouped should not move the dice, instead the pool is %d", pool.List())
+	}
+
+	pool.Sort(false)
+	if !reflect.DeepEqual(pool.List(), []int{2, 2, 5, 6, 6, 6}) {
+		t.Errorf("Sort should put the dice lowest first, instead the pool is %d", pool.List())
+	}
+	pool.Sort(true)
+	if !reflect.DeepEqual(pool.List(), []int{6, 6, 6, 5, 2, 2}) {
+		t.Errorf("Sort descending should put the dice highest first, instead the pool is %d", pool.List())
+	}
+}
+
+func TestDescribeGrouped(t *testing.T) {
+	// Describe should always list the dice. Shown auto, pools of more than GroupOver dice should be grouped
+	pool := dice.CreatePool(dice.GroupOver+1, 6)
+	if description := pool.Describe(); description != "A pool of 11 d6s. The dice are facing 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, and 1." {
+		t.Errorf("Describe should list every die of a big pool, instead got %s", description)
+	}
+	if description := pool.DescribeAs(dice.ShowAuto); description != "A pool of 11 d6s. The dice are facing 1×11." {
+		t.Errorf("A pool over GroupOver should be described grouped when shown auto, instead got %s", description)
+	}
+	if faces := pool.Faces(dice.ShowAuto); faces != "1×11" {
+		t.Errorf("The faces of a pool over GroupOver should be grouped when shown auto, instead got %s", faces)
+	}
+	pool.Subtract()
+	if description := pool.DescribeAs(dice.ShowAuto); description != "A pool of 10 d6s. The dice are facing 1, 1, 1, 1, 1, 1, 1, 1, 1, and 1." {
+		t.Errorf("A pool of GroupOver dice should be listed when shown auto, instead got %s", description)
+	}
+	if faces := pool.Faces(dice.ShowAuto); faces != "[1 1 1 1 1 1 1 1 1 1]" {
+		t.Errorf("The faces of a pool of GroupOver dice should be listed when shown auto, instead got %s", faces)
+	}
+
+	one := dice.CreatePool(1, 6)
+	if description := one.Describe(); description != "A pool of 1 d6s. The dice are facing 1." {
+		t.Errorf("A pool of one die is described wrong: %s", description)
+	}
+	one.Subtract()
+	if description := one.Describe(); description != "A pool of 0 d6s." {
+		t.Errorf("An empty pool is described wrong: %s", description)
+	}
+}
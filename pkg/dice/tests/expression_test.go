@@ -0,0 +1,82 @@
+package dice_test
+
+import (
+	"dicetable/pkg/dice"
+	"strings"
+	"testing"
+)
+
+func TestParseExpression(t *testing.T) {
+	// ParseExpression should read dice, constants and keep or drop modifiers
+	expression, err := dice.ParseExpression("2d6 + d8 - 3 + 4d6kh3 + 2d20kl + d%")
+	if err != nil {
+		t.Fatalf("ParseExpression returned an error: %v", err)
+	}
+	expected := []dice.Term{
+		{Count: 2, Sides: 6},
+		{Count: 1, Sides: 8},
+		{Negative: true, Value: 3},
+		{Count: 4, Sides: 6, Keep: 3},
+		{Count: 2, Sides: 20, Keep: 1, Lowest: true},
+		{Count: 1, Sides: 100},
+	}
+	if len(expression.Terms) != len(expected) {
+		t.Fatalf("ParseExpression should have read %d terms, instead it read %d", len(expected), len(expression.Terms))
+	}
+	for n, term := range expression.Terms {
+		if term != expected[n] {
+			t.Errorf("Term %d should be %+v, instead it is %+v", n, expected[n], term)
+		}
+	}
+
+	// Dropping the lowest is the same as keeping the rest
+	expression, _ = dice.ParseExpression("4d6dl1")
+	if term := expression.Terms[0]; term.Keep != 3 || term.Lowest {
+		t.Errorf("4d6dl1 should keep the highest 3 dice, instead it is %+v", term)
+	}
+
+	for _, bad := range []string{"", "2d", "d6x", "3 4", "4d6kh5", "0d6", "1d0", "2d6++1"} {
+		if _, err := dice.ParseExpression(bad); err == nil {
+			t.Errorf("ParseExpression(%q) should have returned an error", bad)
+		}
+	}
+}
+
+func TestRollExpression(t *testing.T) {
+	// Rolling should keep the right dice and add up every term
+	expression, _ := dice.ParseExpression("4d6kh3+10")
+	for n := 0; n < 100; n++ {
+		result := expression.Roll()
+		faces := result.Terms[0].Faces
+		kept := result.Terms[0].Kept
+
+		lowest, total := 0, 0
+		for i, face := range faces {
+			total += face
+			if face < faces[lowest] {
+				lowest = i
+			}
+		}
+		dropped := 0
+		for i := range kept {
+			if !kept[i] {
+				dropped++
+				if faces[i] != faces[lowest] {
+					t.Fatalf("4d6kh3 dropped %d instead of the lowest die in %d", faces[i], faces)
+				}
+			}
+		}
+		if dropped != 1 || result.Total != total-faces[lowest]+10 {
+			t.Fatalf("4d6kh3+10 rolled %d and totalled %d", faces, result.Total)
+		}
+		if !strings.Contains(result.String(), "(") || !strings.HasPrefix(result.String(), "4d6kh3+10: [") {
+			t.Fatalf("The description should show the dropped die in parentheses, instead it is %s", result.String())
+		}
+	}
+
+	// A d1 always rolls a 1 and a negative term subtracts
+	expression, _ = dice.ParseExpression("3d1-5")
+	if result := expression.Roll(); result.Total != -2 {
+		t.Errorf("3d1-5 should total -2, instead it totalled %d", result.Total)
+	}
+}
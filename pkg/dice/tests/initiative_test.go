@@ -0,0 +1,85 @@
+package dice_test
+
+import (
+	"dicetable/pkg/dice"
+	"testing"
+)
+
+func names(order *dice.Initiative) []string {
+	var list []string
+	for _, combatant := range order.Combatants {
+		list = append(list, combatant.Name)
+	}
+	return list
+}
+
+func TestRollInitiative(t *testing.T) {
+	// Combatants should be ordered by total, then modifier, then tie break
+	dice.Seed(3)
+	order, err := dice.RollInitiative([]string{"a", "b", "c", "d", "e", "f"}, []int{0, 5, -2, 3, 3, 1})
+	if err != nil {
+		t.Fatalf("RollInitiative returned an error: %v", err)
+	}
+	if order.Round != 1 || order.Turn != 0 || len(order.Combatants) != 6 {
+		t.Errorf("Initiative should start at the first turn of round 1, instead it is %+v", order)
+	}
+	for n := 1; n < len(order.Combatants); n++ {
+		a, b := order.Combatants[n-1], order.Combatants[n]
+		if a.Total() < b.Total() || (a.Total() == b.Total() && a.Modifier < b.Modifier) ||
+			(a.Total() == b.Total() && a.Modifier == b.Modifier && a.TieBreak < b.TieBreak) {
+			t.Errorf("%s should not go before %s: %+v %+v", a.Name, b.Name, a, b)
+		}
+	}
+
+	if _, err := dice.RollInitiative([]string{"a", "a"}, []int{0, 0}); err == nil {
+		t.Errorf("A combatant in the order twice should return an error")
+	}
+}
+
+func TestInitiativeTurns(t *testing.T) {
+	// Next and Prev should move the turn and count rounds, and Delay should move the current combatant
+	order := &dice.Initiative{Round: 1, Combatants: []dice.Combatant{{Name: "a", Roll: 20}, {Name: "b", Roll: 15}, {Name: "c", Roll: 10}}}
+
+	order.Prev()
+	if order.Turn != 0 || order.Round != 1 {
+		t.Errorf("Prev should not go before the first turn, instead it is turn %d of round %d", order.Turn, order.Round)
+	}
+	order.Next()
+	order.Next()
+	order.Next()
+	if order.Turn != 0 || order.Round != 2 {
+		t.Errorf("Three turns should start round 2, instead it is turn %d of round %d", order.Turn, order.Round)
+	}
+	order.Prev()
+	if order.Current().Name != "c" || order.Round != 1 {
+		t.Errorf("Prev should go back to c in round 1, instead it is %s in round %d", order.Current().Name, order.Round)
+	}
+	order.Next()
+
+	if err := order.Delay("c"); err != nil {
+		t.Fatalf("Delay returned an error: %v", err)
+	}
+	if got := names(order); got[0] != "b" || got[1] != "c" || got[2] != "a" || order.Current().Name != "b" {
+		t.Errorf("a should go after c and it should be b's turn, instead the order is %s", got)
+	}
+	if err := order.Delay("a"); err != nil || names(order)[1] != "a" || names(order)[2] != "b" {
+		t.Errorf("b should go after a, instead the order is %s and got %v", names(order), err)
+	}
+	order.Next()
+	order.Next()
+	if err := order.Delay(""); err == nil {
+		t.Errorf("The last combatant should not be able to delay")
+	}
+
+	// Adding keeps the turn with the same combatant, removing the current one passes the turn on
+	order.Turn = 1
+	current := order.Current().Name
+	order.Add("z", 100)
+	if order.Current().Name != current || names(order)[0] != "z" {
+		t.Errorf("z should go first and it should still be %s's turn, instead the order is %s", current, names(order))
+	}
+	order.Remove(current)
+	if order.Turn != 2 || len(order.Combatants) != 3 {
+		t.Errorf("Removing the current combatant should pass the turn to the next one, instead it is turn %d", order.Turn)
+	}
+}
@@ -0,0 +1,95 @@
+package dice_test
+
+import (
+	"dicetable/pkg/dice"
+	"testing"
+)
+
+func TestParseCondition(t *testing.T) {
+	// Conditions should read comparisons and lists of faces
+	cases := []struct {
+		text    string
+		matches []int
+		misses  []int
+	}{
+		{"1", []int{1}, []int{2, 6}},
+		{"1,2", []int{1, 2}, []int{3}},
+		{"=6", []int{6}, []int{5}},
+		{"<3", []int{1, 2}, []int{3}},
+		{"<=3", []int{3}, []int{4}},
+		{">5", []int{6}, []int{5}},
+		{">=5", []int{5, 6}, []int{4}},
+	}
+	for _, c := range cases {
+		condition, err := dice.ParseCondition(c.text)
+		if err != nil {
+			t.Errorf("ParseCondition(%q) returned an error: %v", c.text, err)
+			continue
+		}
+		for _, face := range c.matches {
+			if !condition.Match(face) {
+				t.Errorf("%s should match %d", c.text, face)
+			}
+		}
+		for _, face := range c.misses {
+			if condition.Match(face) {
+				t.Errorf("%s should not match %d", c.text, face)
+			}
+		}
+	}
+
+	for _, text := range []string{"", "<", "a", "<1,2"} {
+		if _, err := dice.ParseCondition(text); err == nil {
+			t.Errorf("ParseCondition(%q) should have returned an error", text)
+		}
+	}
+}
+
+func TestRerollDice(t *testing.T) {
+	// Only the dice at the positions should be rerolled, and a position outside the pool should roll nothing
+	pool := dice.CreatePool(4, 6)
+	rerolls, err := pool.RerollDice([]int{1, 3, 3})
+	if err != nil || len(rerolls) != 2 || rerolls[0].Index != 1 || rerolls[1].Index != 3 {
+		t.Fatalf("Dice 1 and 3 should have been rerolled once each, instead got %+v and %v", rerolls, err)
+	}
+	if pool.Dice[0].Top != 1 || pool.Dice[2].Top != 1 || rerolls[0].From() != 1 || rerolls[0].To() != pool.Dice[1].Top {
+		t.Errorf("Only the picked dice should change, instead the pool is %d", pool.List())
+	}
+	if len(pool.History) != 0 {
+		t.Errorf("Rerolls should not be added to the history")
+	}
+	if _, err := pool.RerollDice([]int{0, 4}); err == nil || pool.Dice[0].Top != 1 {
+		t.Errorf("A position outside the pool should return an error without rerolling anything")
+	}
+}
+
+func TestRerollWhere(t *testing.T) {
+	// Rerolling recursively should leave no dice matching the condition
+	dice.Seed(7)
+	pool := dice.CreatePool(50, 6)
+	condition, _ := dice.ParseCondition("<3")
+	rerolls, err := pool.RerollWhere(condition, true)
+	if err != nil || len(rerolls) != 50 {
+		t.Fatalf("All 50 dice showing 1 should have been rerolled, instead got %d and %v", len(rerolls), err)
+	}
+	for _, face := range pool.List() {
+		if face < 3 {
+			t.Fatalf("No dice should be under 3 after a recursive reroll, instead the pool is %d", pool.List())
+		}
+	}
+
+	// Rerolling once should roll each matching die exactly once
+	pool = dice.CreatePool(50, 6)
+	rerolls, _ = pool.RerollWhere(condition, false)
+	for _, r := range rerolls {
+		if len(r.Faces) != 2 {
+			t.Errorf("Each die should be rerolled once, instead die %d went %d", r.Index, r.Faces)
+		}
+	}
+
+	// A condition every face matches can't be rerolled recursively
+	always, _ := dice.ParseCondition("<=6")
+	if _, err := pool.RerollWhere(always, true); err == nil {
+		t.Errorf("Rerolling recursively on a condition every face matches should return an error")
+	}
+}
@@ -0,0 +1,76 @@
+package dice_test
+
+import (
+	"dicetable/pkg/dice"
+	"reflect"
+	"testing"
+)
+
+func TestPoolHistory(t *testing.T) {
+	// Rolling a pool should record the roll and only keep the last MaxHistory rolls
+	pool := dice.CreatePool(3, 6)
+	for n := 0; n < dice.MaxHistory+5; n++ {
+		pool.Roll()
+	}
+	if len(pool.History) != dice.MaxHistory {
+		t.Errorf("The pool should keep %d rolls, instead it has %d", dice.MaxHistory, len(pool.History))
+	}
+	if !reflect.DeepEqual(pool.History[len(pool.History)-1], pool.List()) {
+		t.Errorf("The last roll in the history should be the faces of the pool, instead it is %d", pool.History[len(pool.History)-1])
+	}
+
+	// Copies should not share history with the original
+	copied := pool.Copy()
+	copied.Roll()
+	if len(pool.History) != dice.MaxHistory || !reflect.DeepEqual(pool.History[len(pool.History)-1], pool.List()) {
+		t.Errorf("Rolling a copy should not change the history of the original")
+	}
+}
+
+func TestHistoryStats(t *testing.T) {
+	// The counts, averages and streaks should match the history they were worked out from
+	history := [][]int{{6, 6}, {5, 6}, {1, 2}, {3, 4}, {1, 1}, {2, 2}, {1, 3}}
+	stats := dice.HistoryStats(history, 6)
+
+	if stats.Rolls != 7 || stats.Dice != 14 {
+		t.Errorf("There should be 7 rolls of 14 dice, instead there are %d rolls of %d dice", stats.Rolls, stats.Dice)
+	}
+	if !reflect.DeepEqual(stats.Counts, []int{4, 3, 2, 1, 1, 3}) {
+		t.Errorf("The face counts should be [4 3 2 1 1 3], instead they are %d", stats.Counts)
+	}
+	if stats.Mean != 43.0/14 || stats.Expected != 3.5 || stats.TotalExpected != 7 {
+		t.Errorf("The averages are wrong, got %+v", stats)
+	}
+	// 3 and 4 total exactly 7, which breaks the low streak
+	if stats.HighStreak != 2 || stats.LowStreak != 3 {
+		t.Errorf("The streaks should be 2 high and 3 low, instead they are %d and %d", stats.HighStreak, stats.LowStreak)
+	}
+}
+
+func TestHistoryStatsPValue(t *testing.T) {
+	// Even counts should look fair and loaded dice should not
+	even := [][]int{{1, 2, 3, 4, 5, 6}, {1, 2, 3, 4, 5, 6}, {1, 2, 3, 4, 5, 6}}
+	if stats := dice.HistoryStats(even, 6); stats.ChiSquare != 0 || stats.PValue != 1 {
+		t.Errorf("Dice rolling every face equally should have a p-value of 1, instead got %f", stats.PValue)
+	}
+
+	loaded := make([][]int, 20)
+	for n := range loaded {
+		loaded[n] = []int{6, 6, 6}
+	}
+	if stats := dice.HistoryStats(loaded, 6); stats.PValue > 0.001 {
+		t.Errorf("Dice only rolling sixes should have a tiny p-value, instead got %f", stats.PValue)
+	}
+
+	// Counts of 5, 15, 10, 10, 10, 10 give a chi-square of 5
+	cutoff := [][]int{}
+	for face, count := range []int{5, 15, 10, 10, 10, 10} {
+		for n := 0; n < count; n++ {
+			cutoff = append(cutoff, []int{face + 1})
+		}
+	}
+	stats := dice.HistoryStats(cutoff, 6)
+	if stats.ChiSquare != 5 || stats.PValue < 0.40 || stats.PValue > 0.43 {
+		t.Errorf("A chi-square of 5 with 5 degrees of freedom should have a p-value near 0.416, instead got %f and %f", stats.ChiSquare, stats.PValue)
+	}
+}
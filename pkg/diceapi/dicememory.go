@@ -0,0 +1,391 @@
+package diceapi
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Memory is a Repository kept in maps, for tests and for running without a database.
+// It checks the same unique names and references the database does
+type Memory struct {
+	mutex      sync.Mutex
+	users      map[int]User
+	tables     map[int]Table
+	pools      map[int]Pool
+	characters map[int]Character
+	rolls      map[int]Roll
+	// the last ID given out, shared like a database sequence so IDs are never reused
+	last_id map[string]int
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		users:      make(map[int]User),
+		tables:     make(map[int]Table),
+		pools:      make(map[int]Pool),
+		characters: make(map[int]Character),
+		rolls:      make(map[int]Roll),
+		last_id:    make(map[string]int),
+	}
+}
+
+func (db *Memory) nextID(entity string) int {
+	db.last_id[entity]++
+	return db.last_id[entity]
+}
+
+func sortedIDs(ids []int) []int {
+	sort.Ints(ids)
+	return ids
+}
+
+func (db *Memory) InsertUser(user *User) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	for _, other := range db.users {
+		if other.Username == user.Username {
+			return fmt.Errorf("%w: username %v is taken", ErrDuplicate, user.Username)
+		}
+	}
+	user.ID = db.nextID("users")
+	db.users[user.ID] = storedUser(*user)
+	return nil
+}
+
+func storedUser(user User) User {
+	user.DateCreated = storedTime(user.DateCreated)
+	return user
+}
+
+func (db *Memory) GetUser(id int) (User, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	user, ok := db.users[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (db *Memory) GetUserByName(username string) (User, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	for _, user := range db.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return User{}, ErrNotFound
+}
+
+func (db *Memory) DeleteUser(id int) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if _, ok := db.users[id]; !ok {
+		return ErrNotFound
+	}
+	for _, table := range db.tables {
+		if table.Owner == id {
+			return fmt.Errorf("%w: user %v owns table %v", ErrReference, id, table.ID)
+		}
+	}
+	for _, character := range db.characters {
+		if character.UserID == id {
+			return fmt.Errorf("%w: user %v has character %v", ErrReference, id, character.ID)
+		}
+	}
+	for _, roll := range db.rolls {
+		if roll.UserID == id {
+			return fmt.Errorf("%w: user %v made roll %v", ErrReference, id, roll.ID)
+		}
+	}
+	delete(db.users, id)
+	return nil
+}
+
+func (db *Memory) InsertTable(table *Table) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if err := db.checkTable(*table); err != nil {
+		return err
+	}
+	table.ID = db.nextID("tables")
+	db.tables[table.ID] = storedTable(*table)
+	return nil
+}
+
+func (db *Memory) checkTable(table Table) error {
+	// Table names are unique and the owner must be a user
+	for _, other := range db.tables {
+		if other.Name == table.Name && other.ID != table.ID {
+			return fmt.Errorf("%w: table name %v is taken", ErrDuplicate, table.Name)
+		}
+	}
+	if _, ok := db.users[table.Owner]; !ok {
+		return fmt.Errorf("%w: there is no user %v to own the table", ErrReference, table.Owner)
+	}
+	return nil
+}
+
+func storedTable(table Table) Table {
+	table.DateCreated = storedTime(table.DateCreated)
+	return table
+}
+
+func (db *Memory) GetTable(id int) (Table, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	table, ok := db.tables[id]
+	if !ok {
+		return Table{}, ErrNotFound
+	}
+	return table, nil
+}
+
+func (db *Memory) ListTables(owner int) ([]Table, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	var ids []int
+	for id, table := range db.tables {
+		if table.Owner == owner {
+			ids = append(ids, id)
+		}
+	}
+	tables := []Table{}
+	for _, id := range sortedIDs(ids) {
+		tables = append(tables, db.tables[id])
+	}
+	return tables, nil
+}
+
+func (db *Memory) UpdateTable(table Table) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if _, ok := db.tables[table.ID]; !ok {
+		return ErrNotFound
+	}
+	if err := db.checkTable(table); err != nil {
+		return err
+	}
+	db.tables[table.ID] = storedTable(table)
+	return nil
+}
+
+func (db *Memory) DeleteTable(id int) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if _, ok := db.tables[id]; !ok {
+		return ErrNotFound
+	}
+	for _, pool := range db.pools {
+		if pool.TableID == id {
+			return fmt.Errorf("%w: table %v has pool %v", ErrReference, id, pool.ID)
+		}
+	}
+	for _, character := range db.characters {
+		if character.TableID == id {
+			return fmt.Errorf("%w: table %v has character %v", ErrReference, id, character.ID)
+		}
+	}
+	for _, roll := range db.rolls {
+		if roll.TableID == id {
+			return fmt.Errorf("%w: table %v has roll %v", ErrReference, id, roll.ID)
+		}
+	}
+	delete(db.tables, id)
+	return nil
+}
+
+func (db *Memory) InsertPool(pool *Pool) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if _, ok := db.tables[pool.TableID]; !ok {
+		return fmt.Errorf("%w: there is no table %v for the pool", ErrReference, pool.TableID)
+	}
+	pool.ID = db.nextID("pools")
+	db.pools[pool.ID] = storedPool(*pool)
+	return nil
+}
+
+func storedPool(pool Pool) Pool {
+	pool.Dice = storedDice(pool.Dice)
+	pool.DateCreated = storedTime(pool.DateCreated)
+	return pool
+}
+
+func (db *Memory) GetPool(id int) (Pool, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	pool, ok := db.pools[id]
+	if !ok {
+		return Pool{}, ErrNotFound
+	}
+	// Copy the dice so changing them doesn't change the stored pool
+	return storedPool(pool), nil
+}
+
+func (db *Memory) ListPools(table_id int) ([]Pool, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	var ids []int
+	for id, pool := range db.pools {
+		if pool.TableID == table_id {
+			ids = append(ids, id)
+		}
+	}
+	pools := []Pool{}
+	for _, id := range sortedIDs(ids) {
+		pools = append(pools, storedPool(db.pools[id]))
+	}
+	return pools, nil
+}
+
+func (db *Memory) UpdatePool(pool Pool) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if _, ok := db.pools[pool.ID]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := db.tables[pool.TableID]; !ok {
+		return fmt.Errorf("%w: there is no table %v for the pool", ErrReference, pool.TableID)
+	}
+	db.pools[pool.ID] = storedPool(pool)
+	return nil
+}
+
+func (db *Memory) DeletePool(id int) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if _, ok := db.pools[id]; !ok {
+		return ErrNotFound
+	}
+	for _, roll := range db.rolls {
+		if roll.PoolID == id {
+			return fmt.Errorf("%w: pool %v has roll %v", ErrReference, id, roll.ID)
+		}
+	}
+	delete(db.pools, id)
+	return nil
+}
+
+func (db *Memory) InsertCharacter(character *Character) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if _, ok := db.users[character.UserID]; !ok {
+		return fmt.Errorf("%w: there is no user %v for the character", ErrReference, character.UserID)
+	}
+	if _, ok := db.tables[character.TableID]; !ok {
+		return fmt.Errorf("%w: there is no table %v for the character", ErrReference, character.TableID)
+	}
+	character.ID = db.nextID("characters")
+	stored := *character
+	stored.DateCreated = storedTime(stored.DateCreated)
+	db.characters[character.ID] = stored
+	return nil
+}
+
+func (db *Memory) GetCharacter(id int) (Character, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	character, ok := db.characters[id]
+	if !ok {
+		return Character{}, ErrNotFound
+	}
+	return character, nil
+}
+
+func (db *Memory) ListCharacters(table_id int) ([]Character, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	var ids []int
+	for id, character := range db.characters {
+		if character.TableID == table_id {
+			ids = append(ids, id)
+		}
+	}
+	characters := []Character{}
+	for _, id := range sortedIDs(ids) {
+		characters = append(characters, db.characters[id])
+	}
+	return characters, nil
+}
+
+func (db *Memory) DeleteCharacter(id int) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if _, ok := db.characters[id]; !ok {
+		return ErrNotFound
+	}
+	for _, roll := range db.rolls {
+		if roll.CharacterID == id {
+			return fmt.Errorf("%w: character %v has roll %v", ErrReference, id, roll.ID)
+		}
+	}
+	delete(db.characters, id)
+	return nil
+}
+
+func (db *Memory) InsertRoll(roll *Roll) error {
+	err := checkRoll(roll)
+	if err != nil {
+		return err
+	}
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if _, ok := db.tables[roll.TableID]; !ok {
+		return fmt.Errorf("%w: there is no table %v for the roll", ErrReference, roll.TableID)
+	}
+	if _, ok := db.users[roll.UserID]; !ok {
+		return fmt.Errorf("%w: there is no user %v for the roll", ErrReference, roll.UserID)
+	}
+	if _, ok := db.characters[roll.CharacterID]; !ok {
+		return fmt.Errorf("%w: there is no character %v for the roll", ErrReference, roll.CharacterID)
+	}
+	if _, ok := db.pools[roll.PoolID]; !ok {
+		return fmt.Errorf("%w: there is no pool %v for the roll", ErrReference, roll.PoolID)
+	}
+	roll.ID = db.nextID("rolls")
+	db.rolls[roll.ID] = storedRoll(*roll)
+	return nil
+}
+
+func storedRoll(roll Roll) Roll {
+	roll.DiceBefore = storedDice(roll.DiceBefore)
+	roll.DiceAfter = storedDice(roll.DiceAfter)
+	roll.Date = storedTime(roll.Date)
+	return roll
+}
+
+func (db *Memory) GetRoll(id int) (Roll, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	roll, ok := db.rolls[id]
+	if !ok {
+		return Roll{}, ErrNotFound
+	}
+	return storedRoll(roll), nil
+}
+
+func (db *Memory) ListRolls(table_id int) ([]Roll, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	rolls := []Roll{}
+	for _, roll := range db.rolls {
+		if roll.TableID == table_id {
+			rolls = append(rolls, storedRoll(roll))
+		}
+	}
+	// Oldest first, with rolls made at the same time in the order they were inserted
+	sort.Slice(rolls, func(i, j int) bool {
+		if !rolls[i].Date.Equal(rolls[j].Date) {
+			return rolls[i].Date.Before(rolls[j].Date)
+		}
+		return rolls[i].ID < rolls[j].ID
+	})
+	return rolls, nil
+}
+
+// Both repositories must keep implementing the whole interface
+var _ Repository = (*Memory)(nil)
+var _ Repository = (*Postgres)(nil)
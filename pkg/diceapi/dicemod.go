@@ -36,6 +36,14 @@ type User struct {
 	DateCreated time.Time `json:"DateCreated"`
 }
 
+// The types of change a roll records
+const (
+	RolledDice  = "rolled dice"
+	SetDice     = "set dice"
+	AddedDice   = "added dice"
+	RemovedDice = "removed dice"
+)
+
 type Roll struct {
 	ID           int       `json:"ID"`
 	TableID      int       `json:"TableID"`
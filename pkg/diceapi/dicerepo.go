@@ -0,0 +1,89 @@
+package diceapi
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Errors returned by every Repository, wrapped with the details of what went wrong. Check for them with errors.Is
+var (
+	// there is no row with the ID or name asked for
+	ErrNotFound = errors.New("not found")
+	// a username or table name is already taken
+	ErrDuplicate = errors.New("already exists")
+	// a row refers to a row that doesn't exist, or a row can't be deleted because another still refers to it
+	ErrReference = errors.New("broken reference")
+	// a field has a value the database can't store
+	ErrInvalid = errors.New("invalid value")
+)
+
+type UserRepository interface {
+	// InsertUser sets the ID of the user to the one it was given
+	InsertUser(user *User) error
+	GetUser(id int) (User, error)
+	GetUserByName(username string) (User, error)
+	DeleteUser(id int) error
+}
+
+type TableRepository interface {
+	InsertTable(table *Table) error
+	GetTable(id int) (Table, error)
+	// ListTables returns the tables of an owner in the order they were inserted
+	ListTables(owner int) ([]Table, error)
+	UpdateTable(table Table) error
+	DeleteTable(id int) error
+}
+
+type PoolRepository interface {
+	InsertPool(pool *Pool) error
+	GetPool(id int) (Pool, error)
+	ListPools(table_id int) ([]Pool, error)
+	UpdatePool(pool Pool) error
+	DeletePool(id int) error
+}
+
+type CharacterRepository interface {
+	InsertCharacter(character *Character) error
+	GetCharacter(id int) (Character, error)
+	ListCharacters(table_id int) ([]Character, error)
+	DeleteCharacter(id int) error
+}
+
+// Rolls are a log of what happened at a table, so they are never changed or deleted
+type RollRepository interface {
+	InsertRoll(roll *Roll) error
+	GetRoll(id int) (Roll, error)
+	// ListRolls returns the rolls made at a table oldest first
+	ListRolls(table_id int) ([]Roll, error)
+}
+
+// A Repository stores every diceapi entity. Postgres and Memory both implement it
+type Repository interface {
+	UserRepository
+	TableRepository
+	PoolRepository
+	CharacterRepository
+	RollRepository
+}
+
+func checkRoll(roll *Roll) error {
+	// The type of change must be one of the values of the change type in the database
+	switch roll.TypeOfChange {
+	case RolledDice, SetDice, AddedDice, RemovedDice:
+		return nil
+	}
+	return fmt.Errorf("%w: %v is not a type of change", ErrInvalid, roll.TypeOfChange)
+}
+
+func storedTime(date time.Time) time.Time {
+	// A time as it comes back from a timestamp column, in UTC to the microsecond with the time zone dropped
+	return time.Date(date.Year(), date.Month(), date.Day(), date.Hour(), date.Minute(), date.Second(), date.Nanosecond(), time.UTC).Truncate(time.Microsecond)
+}
+
+func storedDice(dice []int) []int {
+	// A copy of the dice that is never nil, since the dice columns can't be null
+	stored := make([]int, len(dice))
+	copy(stored, dice)
+	return stored
+}
@@ -2,10 +2,13 @@ package diceapi
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"time"
 
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -19,14 +22,13 @@ func Connect(conn_str string) *pgxpool.Pool {
 }
 
 func UserInsert(conn *pgxpool.Pool, username string, password string, date time.Time) error {
-	commandTag, err := conn.Exec(context.Background(), "INSERT INTO users (username, password, date_created) VALUES ($1, $2, $3);", username, password, date)
-	if err != nil {
-		return err
-	}
-	if commandTag.RowsAffected() != 1 {
-		err = fmt.Errorf("did not successfully insert %v into %v", username, date)
-	}
-	return err
+	// Insert a user into the Postgres database. The same as InsertUserInto with NewPostgres(conn)
+	return InsertUserInto(NewPostgres(conn), username, password, date)
+}
+
+func InsertUserInto(users UserRepository, username string, password string, date time.Time) error {
+	user := User{Username: username, Password: password, DateCreated: date}
+	return users.InsertUser(&user)
 }
 
 func Hello() (string, error) {
@@ -42,3 +44,218 @@ func Hello() (string, error) {
 
 	return greeting, err
 }
+
+// Postgres is a Repository kept in a Postgres database migrated with MigrateUp
+type Postgres struct {
+	conn *pgxpool.Pool
+}
+
+func NewPostgres(conn *pgxpool.Pool) *Postgres {
+	return &Postgres{conn: conn}
+}
+
+func sqlError(err error) error {
+	// Turn the errors Postgres returns into the errors of the Repository interface
+	var pg_err *pgconn.PgError
+	if errors.As(err, &pg_err) {
+		switch pg_err.Code {
+		case "23505":
+			return fmt.Errorf("%w: %v", ErrDuplicate, pg_err.Detail)
+		case "23503":
+			return fmt.Errorf("%w: %v", ErrReference, pg_err.Detail)
+		}
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (db *Postgres) exec(sql string, args ...interface{}) error {
+	// Run a statement that changes one row, returning ErrNotFound if there was no row to change
+	commandTag, err := db.conn.Exec(context.Background(), sql, args...)
+	if err != nil {
+		return sqlError(err)
+	}
+	if commandTag.RowsAffected() != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (db *Postgres) InsertUser(user *User) error {
+	err := db.conn.QueryRow(context.Background(), "INSERT INTO users (username, password, date_created) VALUES ($1, $2, $3) RETURNING id;",
+		user.Username, user.Password, user.DateCreated).Scan(&user.ID)
+	return sqlError(err)
+}
+
+func (db *Postgres) GetUser(id int) (User, error) {
+	var user User
+	err := db.conn.QueryRow(context.Background(), "SELECT id, username, password, date_created FROM users WHERE id = $1;", id).
+		Scan(&user.ID, &user.Username, &user.Password, &user.DateCreated)
+	return user, sqlError(err)
+}
+
+func (db *Postgres) GetUserByName(username string) (User, error) {
+	var user User
+	err := db.conn.QueryRow(context.Background(), "SELECT id, username, password, date_created FROM users WHERE username = $1;", username).
+		Scan(&user.ID, &user.Username, &user.Password, &user.DateCreated)
+	return user, sqlError(err)
+}
+
+func (db *Postgres) DeleteUser(id int) error {
+	return db.exec("DELETE FROM users WHERE id = $1;", id)
+}
+
+func (db *Postgres) InsertTable(table *Table) error {
+	err := db.conn.QueryRow(context.Background(), "INSERT INTO tables (table_name, owner, description, date_created) VALUES ($1, $2, $3, $4) RETURNING id;",
+		table.Name, table.Owner, table.Description, table.DateCreated).Scan(&table.ID)
+	return sqlError(err)
+}
+
+func (db *Postgres) GetTable(id int) (Table, error) {
+	var table Table
+	err := db.conn.QueryRow(context.Background(), "SELECT id, table_name, owner, description, date_created FROM tables WHERE id = $1;", id).
+		Scan(&table.ID, &table.Name, &table.Owner, &table.Description, &table.DateCreated)
+	return table, sqlError(err)
+}
+
+func (db *Postgres) ListTables(owner int) ([]Table, error) {
+	rows, err := db.conn.Query(context.Background(), "SELECT id, table_name, owner, description, date_created FROM tables WHERE owner = $1 ORDER BY id;", owner)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+	defer rows.Close()
+	tables := []Table{}
+	for rows.Next() {
+		var table Table
+		err = rows.Scan(&table.ID, &table.Name, &table.Owner, &table.Description, &table.DateCreated)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
+
+func (db *Postgres) UpdateTable(table Table) error {
+	return db.exec("UPDATE tables SET table_name = $2, owner = $3, description = $4, date_created = $5 WHERE id = $1;",
+		table.ID, table.Name, table.Owner, table.Description, table.DateCreated)
+}
+
+func (db *Postgres) DeleteTable(id int) error {
+	return db.exec("DELETE FROM tables WHERE id = $1;", id)
+}
+
+func (db *Postgres) InsertPool(pool *Pool) error {
+	err := db.conn.QueryRow(context.Background(), "INSERT INTO pools (table_id, size_of_dice, dice, description, date_created) VALUES ($1, $2, $3, $4, $5) RETURNING id;",
+		pool.TableID, pool.DiceSize, storedDice(pool.Dice), pool.Description, pool.DateCreated).Scan(&pool.ID)
+	return sqlError(err)
+}
+
+func (db *Postgres) GetPool(id int) (Pool, error) {
+	var pool Pool
+	err := db.conn.QueryRow(context.Background(), "SELECT id, table_id, size_of_dice, dice, description, date_created FROM pools WHERE id = $1;", id).
+		Scan(&pool.ID, &pool.TableID, &pool.DiceSize, &pool.Dice, &pool.Description, &pool.DateCreated)
+	return pool, sqlError(err)
+}
+
+func (db *Postgres) ListPools(table_id int) ([]Pool, error) {
+	rows, err := db.conn.Query(context.Background(), "SELECT id, table_id, size_of_dice, dice, description, date_created FROM pools WHERE table_id = $1 ORDER BY id;", table_id)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+	defer rows.Close()
+	pools := []Pool{}
+	for rows.Next() {
+		var pool Pool
+		err = rows.Scan(&pool.ID, &pool.TableID, &pool.DiceSize, &pool.Dice, &pool.Description, &pool.DateCreated)
+		if err != nil {
+			return nil, err
+		}
+		pools = append(pools, pool)
+	}
+	return pools, rows.Err()
+}
+
+func (db *Postgres) UpdatePool(pool Pool) error {
+	return db.exec("UPDATE pools SET table_id = $2, size_of_dice = $3, dice = $4, description = $5, date_created = $6 WHERE id = $1;",
+		pool.ID, pool.TableID, pool.DiceSize, storedDice(pool.Dice), pool.Description, pool.DateCreated)
+}
+
+func (db *Postgres) DeletePool(id int) error {
+	return db.exec("DELETE FROM pools WHERE id = $1;", id)
+}
+
+func (db *Postgres) InsertCharacter(character *Character) error {
+	err := db.conn.QueryRow(context.Background(), "INSERT INTO characters (name, user_id, table_id, date_created) VALUES ($1, $2, $3, $4) RETURNING id;",
+		character.Name, character.UserID, character.TableID, character.DateCreated).Scan(&character.ID)
+	return sqlError(err)
+}
+
+func (db *Postgres) GetCharacter(id int) (Character, error) {
+	var character Character
+	err := db.conn.QueryRow(context.Background(), "SELECT id, name, user_id, table_id, date_created FROM characters WHERE id = $1;", id).
+		Scan(&character.ID, &character.Name, &character.UserID, &character.TableID, &character.DateCreated)
+	return character, sqlError(err)
+}
+
+func (db *Postgres) ListCharacters(table_id int) ([]Character, error) {
+	rows, err := db.conn.Query(context.Background(), "SELECT id, name, user_id, table_id, date_created FROM characters WHERE table_id = $1 ORDER BY id;", table_id)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+	defer rows.Close()
+	characters := []Character{}
+	for rows.Next() {
+		var character Character
+		err = rows.Scan(&character.ID, &character.Name, &character.UserID, &character.TableID, &character.DateCreated)
+		if err != nil {
+			return nil, err
+		}
+		characters = append(characters, character)
+	}
+	return characters, rows.Err()
+}
+
+func (db *Postgres) DeleteCharacter(id int) error {
+	return db.exec("DELETE FROM characters WHERE id = $1;", id)
+}
+
+func (db *Postgres) InsertRoll(roll *Roll) error {
+	err := checkRoll(roll)
+	if err != nil {
+		return err
+	}
+	err = db.conn.QueryRow(context.Background(), `INSERT INTO rolls (table_id, user_id, character_id, pool_id, type_of_change, dice_before, dice_after, date)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id;`,
+		roll.TableID, roll.UserID, roll.CharacterID, roll.PoolID, roll.TypeOfChange, storedDice(roll.DiceBefore), storedDice(roll.DiceAfter), roll.Date).Scan(&roll.ID)
+	return sqlError(err)
+}
+
+func (db *Postgres) GetRoll(id int) (Roll, error) {
+	var roll Roll
+	err := db.conn.QueryRow(context.Background(), `SELECT id, table_id, user_id, character_id, pool_id, type_of_change::text, dice_before, dice_after, date
+	FROM rolls WHERE id = $1;`, id).
+		Scan(&roll.ID, &roll.TableID, &roll.UserID, &roll.CharacterID, &roll.PoolID, &roll.TypeOfChange, &roll.DiceBefore, &roll.DiceAfter, &roll.Date)
+	return roll, sqlError(err)
+}
+
+func (db *Postgres) ListRolls(table_id int) ([]Roll, error) {
+	rows, err := db.conn.Query(context.Background(), `SELECT id, table_id, user_id, character_id, pool_id, type_of_change::text, dice_before, dice_after, date
+	FROM rolls WHERE table_id = $1 ORDER BY date, id;`, table_id)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+	defer rows.Close()
+	rolls := []Roll{}
+	for rows.Next() {
+		var roll Roll
+		err = rows.Scan(&roll.ID, &roll.TableID, &roll.UserID, &roll.CharacterID, &roll.PoolID, &roll.TypeOfChange, &roll.DiceBefore, &roll.DiceAfter, &roll.Date)
+		if err != nil {
+			return nil, err
+		}
+		rolls = append(rolls, roll)
+	}
+	return rolls, rows.Err()
+}
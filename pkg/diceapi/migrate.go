@@ -0,0 +1,252 @@
+package diceapi
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// The migrations are named [version]_[name].up.sql and [version]_[name].down.sql and run in order of version
+//
+//go:embed migrations/*.sql
+var migration_files embed.FS
+
+// Held while a migration runs so two programs migrating the same database don't both run it
+const migrate_lock = 7268423
+
+type Migration struct {
+	Version int    `json:"Version"`
+	Name    string `json:"Name"`
+	Up      string `json:"Up"`
+	Down    string `json:"Down"`
+}
+
+// A migration and when it was applied to a database. Applied is zero if it hasn't been
+type MigrationStatus struct {
+	Migration
+	Applied time.Time `json:"Applied"`
+}
+
+func Migrations() ([]Migration, error) {
+	// Read the embedded migrations in order of version. Every version from 1 up must have both an up and a down file
+	entries, err := migration_files.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+	by_version := make(map[int]*Migration)
+	for _, entry := range entries {
+		file_name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(file_name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(file_name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %v does not end in .up.sql or .down.sql", file_name)
+		}
+		base := strings.TrimSuffix(file_name, "."+direction+".sql")
+		parts := strings.SplitN(base, "_", 2)
+		version, err := strconv.Atoi(parts[0])
+		if err != nil || len(parts) != 2 || version < 1 {
+			return nil, fmt.Errorf("migration %v is not named [version]_[name]", file_name)
+		}
+		data, err := migration_files.ReadFile(path.Join("migrations", file_name))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := by_version[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: parts[1]}
+			by_version[version] = migration
+		} else if migration.Name != parts[1] {
+			return nil, fmt.Errorf("migrations %v and %v have the same version", migration.Name, parts[1])
+		}
+		if direction == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(by_version))
+	for _, migration := range by_version {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for n, migration := range migrations {
+		if migration.Version != n+1 {
+			return nil, fmt.Errorf("migration %v is missing", n+1)
+		}
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %v_%v needs both an up and a down file", migration.Version, migration.Name)
+		}
+	}
+	return migrations, nil
+}
+
+func createSchemaVersion(conn *pgxpool.Pool) error {
+	// schema_version has a row for each migration applied to the database
+	_, err := conn.Exec(context.Background(), `CREATE TABLE IF NOT EXISTS schema_version (
+	version int NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	applied timestamp NOT NULL
+);`)
+	return err
+}
+
+func SchemaVersion(conn *pgxpool.Pool) (int, error) {
+	// The version of the last migration applied to the database, 0 if none have been
+	err := createSchemaVersion(conn)
+	if err != nil {
+		return 0, err
+	}
+	var version int
+	err = conn.QueryRow(context.Background(), "SELECT COALESCE(MAX(version), 0) FROM schema_version;").Scan(&version)
+	return version, err
+}
+
+func MigrateUp(conn *pgxpool.Pool) ([]Migration, error) {
+	// Apply every migration the database doesn't have yet, in order. Returns the migrations that were applied.
+	// Each runs in its own transaction, so a failed migration leaves the database at the version before it
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	err = createSchemaVersion(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range migrations {
+		ran, err := migrateStep(conn, migration.Version-1, migration.Up,
+			"INSERT INTO schema_version (version, name, applied) VALUES ($1, $2, $3);", migration.Version, migration.Name, time.Now())
+		if err != nil {
+			return applied, fmt.Errorf("migration %v_%v failed: %w", migration.Version, migration.Name, err)
+		}
+		if ran {
+			applied = append(applied, migration)
+		}
+	}
+	return applied, nil
+}
+
+func MigrateDown(conn *pgxpool.Pool, steps int) ([]Migration, error) {
+	// Undo the last steps migrations applied to the database, newest first. Returns the migrations that were undone
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	version, err := SchemaVersion(conn)
+	if err != nil {
+		return nil, err
+	}
+	if version > len(migrations) {
+		return nil, fmt.Errorf("the database is at version %v but only %v migrations are known", version, len(migrations))
+	}
+
+	var undone []Migration
+	for ; steps > 0 && version > 0; steps-- {
+		migration := migrations[version-1]
+		ran, err := migrateStep(conn, migration.Version, migration.Down, "DELETE FROM schema_version WHERE version = $1;", migration.Version)
+		if err != nil {
+			return undone, fmt.Errorf("undoing migration %v_%v failed: %w", migration.Version, migration.Name, err)
+		}
+		if !ran {
+			// Another program changed the version while this one was waiting for the lock
+			break
+		}
+		undone = append(undone, migration)
+		version--
+	}
+	return undone, nil
+}
+
+func migrateStep(conn *pgxpool.Pool, from int, sql string, record string, args ...interface{}) (bool, error) {
+	// Run the sql of a migration and record it in schema_version if the database is still at version from.
+	// Returns false without running anything if it isn't
+	ctx := context.Background()
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1);", migrate_lock)
+	if err != nil {
+		return false, err
+	}
+	var version int
+	err = tx.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_version;").Scan(&version)
+	if err != nil {
+		return false, err
+	}
+	if version != from {
+		return false, nil
+	}
+
+	_, err = tx.Exec(ctx, sql)
+	if err != nil {
+		return false, err
+	}
+	_, err = tx.Exec(ctx, record, args...)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+func MigrateStatus(conn *pgxpool.Pool) ([]MigrationStatus, error) {
+	// Every known migration and when it was applied to the database
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	err = createSchemaVersion(conn)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := conn.Query(context.Background(), "SELECT version, applied FROM schema_version;")
+	if err != nil {
+		return nil, err
+	}
+	applied, err := scanApplied(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(migrations))
+	for n, migration := range migrations {
+		statuses[n] = MigrationStatus{Migration: migration, Applied: applied[migration.Version]}
+		delete(applied, migration.Version)
+	}
+	for version := range applied {
+		return statuses, fmt.Errorf("the database has migration %v applied, which is not known", version)
+	}
+	return statuses, nil
+}
+
+func scanApplied(rows pgx.Rows) (map[int]time.Time, error) {
+	defer rows.Close()
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var date time.Time
+		err := rows.Scan(&version, &date)
+		if err != nil {
+			return nil, err
+		}
+		applied[version] = date
+	}
+	return applied, rows.Err()
+}
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS users (
+	id SERIAL NOT NULL PRIMARY KEY,
+	username VARCHAR(255) UNIQUE NOT NULL,
+	password VARCHAR(255),
+	date_created timestamp NOT NULL
+);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS tables (
+	id SERIAL NOT NULL PRIMARY KEY,
+	table_name VARCHAR(255) UNIQUE NOT NULL,
+	owner int NOT NULL references users(id),
+	description VARCHAR(255),
+	date_created timestamp NOT NULL
+);
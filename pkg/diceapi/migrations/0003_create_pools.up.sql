@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS pools (
+	id SERIAL NOT NULL PRIMARY KEY,
+	table_id int NOT NULL references tables(id),
+	size_of_dice int NOT NULL,
+	dice int[] NOT NULL,
+	description VARCHAR(255),
+	date_created timestamp NOT NULL
+);
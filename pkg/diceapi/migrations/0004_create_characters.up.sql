@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS characters (
+	id SERIAL NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	user_id int NOT NULL REFERENCES users(id),
+	table_id int NOT NULL REFERENCES tables(id),
+	date_created timestamp NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS rolls;
+DROP TYPE IF EXISTS change;
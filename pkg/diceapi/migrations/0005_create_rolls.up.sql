@@ -0,0 +1,19 @@
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'change') THEN
+        CREATE TYPE change AS ENUM ('rolled dice', 'set dice', 'added dice', 'removed dice');
+    END IF;
+END
+$$;
+
+CREATE TABLE IF NOT EXISTS rolls(
+	id SERIAL NOT NULL PRIMARY KEY,
+	table_id int NOT NULL references tables(id),
+	user_id int NOT NULL REFERENCES users(id),
+	character_id int NOT NULL REFERENCES characters(id),
+	pool_id int NOT NULL references pools(id),
+	type_of_change change NOT NULL,
+	dice_before int[] NOT NULL,
+	dice_after int[] NOT NULL,
+	date timestamp NOT NULL
+);
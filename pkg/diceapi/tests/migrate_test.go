@@ -0,0 +1,70 @@
+package diceapi_test
+
+import (
+	"testing"
+
+	"dicetable/pkg/diceapi"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := diceapi.Migrations()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(migrations) != 5 {
+		t.Errorf("There should be 5 migrations, instead there are %v", len(migrations))
+	}
+	for n, migration := range migrations {
+		if migration.Version != n+1 {
+			t.Errorf("Migration %v has version %v", n+1, migration.Version)
+		}
+		if migration.Up == "" || migration.Down == "" {
+			t.Errorf("Migration %v_%v is missing its up or down sql", migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	conn := testDatabase(t)
+	defer conn.Close()
+	migrations, _ := diceapi.Migrations()
+
+	// Leave the database migrated for the other tests, however far the test got
+	defer diceapi.MigrateUp(conn)
+	_, err := diceapi.MigrateUp(conn)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	version, err := diceapi.SchemaVersion(conn)
+	if err != nil || version != len(migrations) {
+		t.Errorf("The database should be at version %v, instead it is at %v %v", len(migrations), version, err)
+	}
+	applied, err := diceapi.MigrateUp(conn)
+	if err != nil || len(applied) != 0 {
+		t.Errorf("Migrating an up to date database should apply nothing, applied %v %v", applied, err)
+	}
+
+	undone, err := diceapi.MigrateDown(conn, 2)
+	if err != nil || len(undone) != 2 || undone[0].Version != len(migrations) {
+		t.Errorf("The last 2 migrations should have been undone newest first, undid %v %v", undone, err)
+	}
+	statuses, err := diceapi.MigrateStatus(conn)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	for _, status := range statuses {
+		pending := status.Version > len(migrations)-2
+		if status.Applied.IsZero() != pending {
+			t.Errorf("Migration %v_%v should be pending: %v, applied at %v", status.Version, status.Name, pending, status.Applied)
+		}
+	}
+
+	undone, err = diceapi.MigrateDown(conn, len(migrations))
+	if err != nil || len(undone) != len(migrations)-2 {
+		t.Errorf("The rest of the migrations should have been undone, undid %v %v", undone, err)
+	}
+	applied, err = diceapi.MigrateUp(conn)
+	if err != nil || len(applied) != len(migrations) {
+		t.Errorf("Every migration should apply to an empty database, applied %v %v", applied, err)
+	}
+}
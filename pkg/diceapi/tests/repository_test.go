@@ -0,0 +1,192 @@
+package diceapi_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"dicetable/pkg/diceapi"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, diceapi.NewMemory())
+}
+
+func TestPostgresRepository(t *testing.T) {
+	conn := testDatabase(t)
+	defer conn.Close()
+
+	// Start from an empty database
+	migrations, _ := diceapi.Migrations()
+	_, err := diceapi.MigrateDown(conn, len(migrations))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	_, err = diceapi.MigrateUp(conn)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	testRepository(t, diceapi.NewPostgres(conn))
+}
+
+func testRepository(t *testing.T, repo diceapi.Repository) {
+	// Every Repository must pass these, so code written against one works with the others
+	date := time.Date(2021, 8, 1, 12, 30, 15, 0, time.UTC)
+
+	// Users
+	user := diceapi.User{Username: "robert", Password: "password1", DateCreated: date}
+	if err := repo.InsertUser(&user); err != nil || user.ID == 0 {
+		t.Fatalf("InsertUser should set an ID, got %v %v", user.ID, err)
+	}
+	player := diceapi.User{Username: "player", DateCreated: date}
+	if err := repo.InsertUser(&player); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if got, err := repo.GetUser(user.ID); err != nil || !reflect.DeepEqual(got, user) {
+		t.Errorf("GetUser should return %v, got %v %v", user, got, err)
+	}
+	if got, err := repo.GetUserByName("player"); err != nil || !reflect.DeepEqual(got, player) {
+		t.Errorf("GetUserByName should return %v, got %v %v", player, got, err)
+	}
+	duplicate := diceapi.User{Username: "robert", DateCreated: date}
+	if err := repo.InsertUser(&duplicate); !errors.Is(err, diceapi.ErrDuplicate) {
+		t.Errorf("A taken username should return ErrDuplicate, got %v", err)
+	}
+	if _, err := repo.GetUser(user.ID + 1000); !errors.Is(err, diceapi.ErrNotFound) {
+		t.Errorf("A missing user should return ErrNotFound, got %v", err)
+	}
+	if _, err := repo.GetUserByName("nobody"); !errors.Is(err, diceapi.ErrNotFound) {
+		t.Errorf("A missing username should return ErrNotFound, got %v", err)
+	}
+
+	// Tables
+	orphan := diceapi.Table{Name: "orphan", Owner: user.ID + 1000, DateCreated: date}
+	if err := repo.InsertTable(&orphan); !errors.Is(err, diceapi.ErrReference) {
+		t.Errorf("A table without an owner should return ErrReference, got %v", err)
+	}
+	table := diceapi.Table{Name: "camp", Owner: user.ID, Description: "The first session", DateCreated: date}
+	if err := repo.InsertTable(&table); err != nil || table.ID == 0 {
+		t.Fatalf("InsertTable should set an ID, got %v %v", table.ID, err)
+	}
+	other := diceapi.Table{Name: "dungeon", Owner: user.ID, DateCreated: date}
+	if err := repo.InsertTable(&other); err != nil {
+		t.Fatalf("%v", err)
+	}
+	duplicate_table := diceapi.Table{Name: "camp", Owner: player.ID, DateCreated: date}
+	if err := repo.InsertTable(&duplicate_table); !errors.Is(err, diceapi.ErrDuplicate) {
+		t.Errorf("A taken table name should return ErrDuplicate, got %v", err)
+	}
+	table.Description = "The second session"
+	if err := repo.UpdateTable(table); err != nil {
+		t.Errorf("%v", err)
+	}
+	if got, err := repo.GetTable(table.ID); err != nil || !reflect.DeepEqual(got, table) {
+		t.Errorf("GetTable should return the updated table %v, got %v %v", table, got, err)
+	}
+	other.Name = "camp"
+	if err := repo.UpdateTable(other); !errors.Is(err, diceapi.ErrDuplicate) {
+		t.Errorf("Renaming a table to a taken name should return ErrDuplicate, got %v", err)
+	}
+	other.Name = "dungeon"
+	if err := repo.UpdateTable(diceapi.Table{ID: other.ID + 1000, Name: "missing", Owner: user.ID, DateCreated: date}); !errors.Is(err, diceapi.ErrNotFound) {
+		t.Errorf("Updating a missing table should return ErrNotFound, got %v", err)
+	}
+	tables, err := repo.ListTables(user.ID)
+	if err != nil || !reflect.DeepEqual(tables, []diceapi.Table{table, other}) {
+		t.Errorf("ListTables should return both tables in order, got %v %v", tables, err)
+	}
+	if tables, err := repo.ListTables(player.ID); err != nil || len(tables) != 0 {
+		t.Errorf("ListTables should return no tables for a user without any, got %v %v", tables, err)
+	}
+
+	// Pools
+	pool := diceapi.Pool{TableID: table.ID, Description: "strength", DiceSize: 6, Dice: []int{1, 4, 6, 3}, DateCreated: date}
+	if err := repo.InsertPool(&pool); err != nil || pool.ID == 0 {
+		t.Fatalf("InsertPool should set an ID, got %v %v", pool.ID, err)
+	}
+	missing_table := diceapi.Pool{TableID: other.ID + 1000, DiceSize: 6, Dice: []int{1}, DateCreated: date}
+	if err := repo.InsertPool(&missing_table); !errors.Is(err, diceapi.ErrReference) {
+		t.Errorf("A pool without a table should return ErrReference, got %v", err)
+	}
+	pool.Dice[0] = 5
+	if got, err := repo.GetPool(pool.ID); err != nil || got.Dice[0] != 1 {
+		t.Errorf("Changing the dice after inserting the pool shouldn't change the stored pool, got %v %v", got, err)
+	}
+	if err := repo.UpdatePool(pool); err != nil {
+		t.Errorf("%v", err)
+	}
+	pools, err := repo.ListPools(table.ID)
+	if err != nil || !reflect.DeepEqual(pools, []diceapi.Pool{pool}) {
+		t.Errorf("ListPools should return the updated pool %v, got %v %v", pool, pools, err)
+	}
+
+	// Characters
+	character := diceapi.Character{Name: "Tordek", UserID: player.ID, TableID: table.ID, DateCreated: date}
+	if err := repo.InsertCharacter(&character); err != nil || character.ID == 0 {
+		t.Fatalf("InsertCharacter should set an ID, got %v %v", character.ID, err)
+	}
+	homeless := diceapi.Character{Name: "Lidda", UserID: player.ID + 1000, TableID: table.ID, DateCreated: date}
+	if err := repo.InsertCharacter(&homeless); !errors.Is(err, diceapi.ErrReference) {
+		t.Errorf("A character without a user should return ErrReference, got %v", err)
+	}
+	if got, err := repo.GetCharacter(character.ID); err != nil || !reflect.DeepEqual(got, character) {
+		t.Errorf("GetCharacter should return %v, got %v %v", character, got, err)
+	}
+	characters, err := repo.ListCharacters(table.ID)
+	if err != nil || !reflect.DeepEqual(characters, []diceapi.Character{character}) {
+		t.Errorf("ListCharacters should return %v, got %v %v", character, characters, err)
+	}
+
+	// Rolls
+	later := diceapi.Roll{TableID: table.ID, UserID: player.ID, CharacterID: character.ID, PoolID: pool.ID,
+		TypeOfChange: diceapi.RolledDice, DiceBefore: []int{5, 4, 6, 3}, DiceAfter: []int{2, 2, 6, 1}, Date: date.Add(time.Minute)}
+	if err := repo.InsertRoll(&later); err != nil || later.ID == 0 {
+		t.Fatalf("InsertRoll should set an ID, got %v %v", later.ID, err)
+	}
+	earlier := diceapi.Roll{TableID: table.ID, UserID: player.ID, CharacterID: character.ID, PoolID: pool.ID,
+		TypeOfChange: diceapi.SetDice, DiceBefore: []int{1, 4, 6, 3}, DiceAfter: []int{5, 4, 6, 3}, Date: date}
+	if err := repo.InsertRoll(&earlier); err != nil {
+		t.Fatalf("%v", err)
+	}
+	invalid := earlier
+	invalid.TypeOfChange = "cheated"
+	if err := repo.InsertRoll(&invalid); !errors.Is(err, diceapi.ErrInvalid) {
+		t.Errorf("A roll with an unknown type of change should return ErrInvalid, got %v", err)
+	}
+	broken := earlier
+	broken.PoolID = pool.ID + 1000
+	if err := repo.InsertRoll(&broken); !errors.Is(err, diceapi.ErrReference) {
+		t.Errorf("A roll without a pool should return ErrReference, got %v", err)
+	}
+	if got, err := repo.GetRoll(later.ID); err != nil || !reflect.DeepEqual(got, later) {
+		t.Errorf("GetRoll should return %v, got %v %v", later, got, err)
+	}
+	rolls, err := repo.ListRolls(table.ID)
+	if err != nil || !reflect.DeepEqual(rolls, []diceapi.Roll{earlier, later}) {
+		t.Errorf("ListRolls should return the rolls oldest first, got %v %v", rolls, err)
+	}
+
+	// Rows can't be deleted while others refer to them
+	if err := repo.DeleteUser(player.ID); !errors.Is(err, diceapi.ErrReference) {
+		t.Errorf("Deleting a user with characters should return ErrReference, got %v", err)
+	}
+	if err := repo.DeleteTable(table.ID); !errors.Is(err, diceapi.ErrReference) {
+		t.Errorf("Deleting a table with pools should return ErrReference, got %v", err)
+	}
+	if err := repo.DeletePool(pool.ID); !errors.Is(err, diceapi.ErrReference) {
+		t.Errorf("Deleting a pool with rolls should return ErrReference, got %v", err)
+	}
+	if err := repo.DeleteCharacter(character.ID); !errors.Is(err, diceapi.ErrReference) {
+		t.Errorf("Deleting a character with rolls should return ErrReference, got %v", err)
+	}
+	if err := repo.DeleteTable(other.ID); err != nil {
+		t.Errorf("Deleting a table nothing refers to should work, got %v", err)
+	}
+	if _, err := repo.GetTable(other.ID); !errors.Is(err, diceapi.ErrNotFound) {
+		t.Errorf("A deleted table should return ErrNotFound, got %v", err)
+	}
+	if err := repo.DeleteTable(other.ID); !errors.Is(err, diceapi.ErrNotFound) {
+		t.Errorf("Deleting a table twice should return ErrNotFound, got %v", err)
+	}
+}